@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// cliFlags holds the command-line flags of the server
+type cliFlags struct {
+	configFile  string
+	printConfig bool
+	// overrides are settings given on the command line, keyed by setting name
+	overrides map[string]string
+}
+
+// settingFlags collects repeated -set name=value flags
+type settingFlags map[string]string
+
+// String implements the flag.Value interface
+func (s settingFlags) String() string {
+	return ""
+}
+
+// Set implements the flag.Value interface
+func (s settingFlags) Set(value string) error {
+	name, setting, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("expected name=value but got %q", value)
+	}
+	s[strings.TrimSpace(name)] = setting
+	return nil
+}
+
+// parseFlags parses the command line, reporting errors and usage to output. Shorthand flags
+// such as -port set the same settings as -set and take precedence over it.
+func parseFlags(args []string, output io.Writer) (*cliFlags, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	flags := &cliFlags{overrides: make(map[string]string)}
+	settings := make(settingFlags)
+
+	fs.StringVar(&flags.configFile, "config", "", "read settings from this YAML or TOML (.toml) file (default $CONFIG_FILE)")
+	fs.BoolVar(&flags.printConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	fs.Var(settings, "set", "set a setting, e.g. -set SERVER_PORT=9090 or -set server.port=9090 (repeatable)")
+	port := fs.Int("port", 0, "port to listen on (SERVER_PORT)")
+	logLevel := fs.String("log-level", "", "minimum log level: debug, info, warn or error (LOG_LEVEL)")
+	modelsBaseURL := fs.String("modelslab-base-url", "", "ModelsLab API base URL (MODELSLAB_BASE_URL)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		err := fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		return nil, err
+	}
+
+	for name, value := range settings {
+		flags.overrides[name] = value
+	}
+	if *port != 0 {
+		flags.overrides["SERVER_PORT"] = strconv.Itoa(*port)
+	}
+	if *logLevel != "" {
+		flags.overrides["LOG_LEVEL"] = *logLevel
+	}
+	if *modelsBaseURL != "" {
+		flags.overrides["MODELSLAB_BASE_URL"] = *modelsBaseURL
+	}
+
+	return flags, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		want       map[string]string
+		wantErr    bool
+		wantOutput string
+	}{
+		{
+			name: "shorthand flags take precedence over -set",
+			args: []string{"-set", "SERVER_PORT=1", "-set", "server.timeout=5s", "-port", "9090", "-log-level", "debug"},
+			want: map[string]string{"SERVER_PORT": "9090", "server.timeout": "5s", "LOG_LEVEL": "debug"},
+		},
+		{
+			name:       "stray arguments",
+			args:       []string{"-port", "9090", "serve", "now"},
+			wantErr:    true,
+			wantOutput: "unexpected arguments: serve now",
+		},
+		{
+			name:       "malformed setting",
+			args:       []string{"-set", "SERVER_PORT"},
+			wantErr:    true,
+			wantOutput: `expected name=value but got "SERVER_PORT"`,
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"-verbose"},
+			wantErr:    true,
+			wantOutput: "flag provided but not defined: -verbose",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			flags, err := parseFlags(tt.args, &output)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				if !strings.Contains(output.String(), tt.wantOutput) {
+					t.Errorf("expected %q to be reported, got %q", tt.wantOutput, output.String())
+				}
+				if !strings.Contains(output.String(), "Usage of server") {
+					t.Errorf("expected usage to be printed, got %q", output.String())
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(flags.overrides) != len(tt.want) {
+				t.Fatalf("got overrides %v, want %v", flags.overrides, tt.want)
+			}
+			for name, value := range tt.want {
+				if flags.overrides[name] != value {
+					t.Errorf("%s = %q, want %q", name, flags.overrides[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFlagsHelp(t *testing.T) {
+	var output bytes.Buffer
+	if _, err := parseFlags([]string{"-h"}, &output); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("expected flag.ErrHelp, got %v", err)
+	}
+	if !strings.Contains(output.String(), "-print-config") {
+		t.Errorf("expected usage to list the flags, got %q", output.String())
+	}
+}
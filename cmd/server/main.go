@@ -2,66 +2,361 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
-	"time"
 
 	"image/internal/app"
+	"image/internal/domain/models"
 	"image/internal/domain/ports"
+	apikeyshandler "image/internal/handlers/apikeys"
+	audithandler "image/internal/handlers/audit"
+	batchhandler "image/internal/handlers/batch"
+	estimatehandler "image/internal/handlers/estimate"
+	generationshandler "image/internal/handlers/generations"
 	"image/internal/handlers/health"
+	jobshandler "image/internal/handlers/jobs"
+	loglevelhandler "image/internal/handlers/loglevel"
+	metricshandler "image/internal/handlers/metrics"
 	modelshandler "image/internal/handlers/models"
+	sweephandler "image/internal/handlers/sweep"
 	"image/internal/handlers/text2img"
+	upstreamkeyshandler "image/internal/handlers/upstreamkeys"
+	usagehandler "image/internal/handlers/usage"
+	"image/internal/infrastructure/auth"
 	"image/internal/infrastructure/config"
 	"image/internal/infrastructure/http"
+	"image/internal/infrastructure/keypool"
+	"image/internal/infrastructure/metrics"
+	"image/internal/infrastructure/probes"
 	registry "image/internal/infrastructure/registry"
+	"image/internal/infrastructure/storage"
+	"image/internal/infrastructure/tracing"
 	"image/internal/infrastructure/validation"
+	"image/internal/services/apikeys"
+	auditservice "image/internal/services/audit"
+	"image/internal/services/batch"
+	"image/internal/services/estimate"
+	"image/internal/services/generations"
+	healthservice "image/internal/services/health"
+	"image/internal/services/jobs"
+	"image/internal/services/loglevel"
 	"image/internal/services/modelslab"
+	"image/internal/services/rbac"
+	"image/internal/services/sweep"
+	"image/internal/services/upstreamkeys"
+	"image/internal/services/usage"
 	"image/pkg/logger"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 func main() {
+	// Parse the command line; errors and usage are reported on stderr
+	flags, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		os.Exit(2)
+	}
+
 	// Initialize logger
 	appLogger := logger.New()
 
 	// Load configuration
-	cfg, err := config.New()
+	cfg, err := config.New(
+		config.WithFile(flags.configFile),
+		config.WithOverrides(flags.overrides),
+	)
 	if err != nil {
 		appLogger.Error("Failed to load configuration", err)
 		os.Exit(1)
 	}
 
+	if flags.printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			appLogger.Error("Failed to print configuration", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Reconfigure the logger now that its settings are known
+	appLogger = logger.New(
+		logger.WithFormat(cfg.Logging.Format),
+		logger.WithLevel(cfg.Logging.Level),
+		logger.WithContextFields(requestLogFields),
+	)
+	appLogger.SetRedaction(cfg.Logging.RedactFields, cfg.Logging.RedactPrompts)
+
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		appLogger.Error("Failed to initialize tracing", err)
+		os.Exit(1)
+	}
+
 	// Initialize validator
 	validator := validation.New()
 
+	// Initialize the pool of ModelsLab API keys
+	keyPool, err := keypool.NewPool(
+		keypool.NewSource(cfg.ModelsLab.Keys, cfg.ModelsLab.KeysFile),
+		cfg.ModelsLab.KeyStrategy,
+		cfg.ModelsLab.KeyCooldown,
+		appLogger,
+	)
+	if err != nil {
+		appLogger.Error("Failed to load ModelsLab API keys", err)
+		os.Exit(1)
+	}
+
+	// Initialize metrics; the collector is only wired in when metrics are enabled
+	collector := metrics.NewCollector()
+
 	// Initialize HTTP client
+	clientOpts := []http.ClientOption{
+		http.WithMaxRetries(cfg.ModelsLab.MaxRetries),
+		http.WithTimeout(cfg.ModelsLab.Timeout),
+		http.WithKeyPool(keyPool),
+	}
+	if cfg.Metrics.Enabled {
+		clientOpts = append(clientOpts, http.WithMetrics(collector))
+	}
 	httpClient := http.NewClient(
 		cfg.ModelsLab.BaseURL,
-		cfg.ModelsLab.APIKey,
+		cfg.ModelsLab.APIKey.Reveal(),
 		appLogger,
-		http.WithMaxRetries(cfg.ModelsLab.MaxRetries),
-		http.WithTimeout(30*time.Second),
+		clientOpts...,
 	)
 
-	// Initialize model registry
+	// Initialize model registry with the catalog's models, which replace built-in ones
 	modelRegistry := registry.NewModelRegistry()
+	for _, definition := range cfg.Models {
+		if err := modelRegistry.Register(models.NewBaseModel(definition.ID, definition.Capabilities())); err != nil {
+			appLogger.Error("Invalid model catalog", err, "model_id", definition.ID)
+			os.Exit(1)
+		}
+	}
+
+	// Initialize repositories
+	generationRepository := storage.NewGenerationRepository(cfg.Storage.MaxGenerations)
+	apiKeyRepository, err := storage.NewAPIKeyRepository(cfg.Auth.KeysFile)
+	if err != nil {
+		appLogger.Error("Failed to load API keys", err)
+		os.Exit(1)
+	}
+	usageRepository, err := storage.NewUsageRepository(cfg.Usage.File)
+	if err != nil {
+		appLogger.Error("Failed to load usage records", err)
+		os.Exit(1)
+	}
+	auditRepository, err := storage.NewAuditRepository(cfg.Audit.File, []byte(cfg.Audit.HMACKey.Reveal()))
+	if err != nil {
+		appLogger.Error("Failed to load audit log", err)
+		os.Exit(1)
+	}
+	pendingJobRepository, err := storage.NewPendingJobRepository(cfg.Storage.PendingJobsFile)
+	if err != nil {
+		appLogger.Error("Failed to load pending jobs", err)
+		os.Exit(1)
+	}
+	if err := auditRepository.Verify(context.Background()); err != nil {
+		appLogger.Error("Audit log failed verification; it may have been tampered with, readiness will fail", err)
+	}
+	if cfg.Audit.HMACKey.IsEmpty() {
+		appLogger.Info("AUDIT_HMAC_KEY is not set; the audit log hash chain is unkeyed and only detects accidental corruption")
+	}
+
+	// Record the server's own configuration changes in the audit log
+	auditService := auditservice.NewService(auditRepository, appLogger)
+	systemCtx := models.SystemContext(context.Background())
+	auditService.Record(systemCtx, models.AuditConfigLoad, "server", nil)
 
 	// Initialize services
-	modelsLabService := modelslab.NewService(httpClient, validator, appLogger, modelRegistry)
+	accessPolicy := rbac.NewEnforcer(cfg.RolePolicies, appLogger)
+	jobTracker := jobs.NewTracker()
+	modelsLabOpts := []modelslab.ServiceOption{
+		modelslab.WithGenerationRepository(generationRepository),
+		modelslab.WithJobTracker(jobTracker),
+		modelslab.WithPendingJobRepository(pendingJobRepository),
+		modelslab.WithAccessPolicy(accessPolicy),
+		modelslab.WithKeyPool(keyPool),
+		modelslab.WithCancelEndpoint(cfg.ModelsLab.CancelEndpoint),
+		modelslab.WithRequestLimits(cfg.Limits.MaxPixels, cfg.Limits.MaxSamples),
+		modelslab.WithPollingStrategy(modelslab.PollingStrategy{
+			MaxWait:      cfg.Polling.MaxWait,
+			BaseInterval: cfg.Polling.BaseInterval,
+			MaxInterval:  cfg.Polling.MaxInterval,
+			Multiplier:   cfg.Polling.Multiplier,
+			Endpoints:    cfg.Polling.Endpoints,
+		}),
+	}
+	if cfg.RateLimit.Enabled {
+		modelsLabOpts = append(modelsLabOpts, modelslab.WithModelRateLimits(cfg.RateLimit.Models))
+	}
+	if cfg.Metrics.Enabled {
+		modelsLabOpts = append(modelsLabOpts, modelslab.WithMetrics(collector))
+	}
+	modelsLabService := modelslab.NewService(httpClient, validator, appLogger, modelRegistry, modelsLabOpts...)
+	for _, model := range modelRegistry.List() {
+		auditService.Record(systemCtx, models.AuditModelRegister, model.ID(), nil)
+	}
+
+	// Meter every generation and enforce quotas before it reaches ModelsLab
+	quotaOverrides := make(map[string]models.Quota, len(cfg.Usage.PrincipalImageQuotas))
+	for key, quota := range cfg.Usage.PrincipalImageQuotas {
+		quotaOverrides[key] = models.Quota{DailyImages: quota.Daily, MonthlyImages: quota.Monthly}
+	}
+	tenantQuotas := make(map[string]models.Quota, len(cfg.Tenants))
+	for tenantID, tenant := range cfg.Tenants {
+		tenantQuotas[tenantID] = models.Quota{DailyImages: tenant.DailyImageQuota, MonthlyImages: tenant.MonthlyImageQuota}
+	}
+	usageService := usage.NewService(
+		modelsLabService,
+		usageRepository,
+		appLogger,
+		usage.WithDefaultQuota(models.Quota{
+			DailyImages:   cfg.Usage.DailyImageQuota,
+			MonthlyImages: cfg.Usage.MonthlyImageQuota,
+		}),
+		usage.WithQuotaOverrides(quotaOverrides),
+		usage.WithTenantQuotas(tenantQuotas),
+		usage.WithModelRegistry(modelRegistry),
+	)
+
+	// Record every generation attempt, including those refused by quotas
+	generator := auditservice.NewGenerator(usageService, auditService)
+
+	// Apply pricing now that the service has registered the supported models
+	for modelID, pricing := range cfg.Pricing {
+		err := modelRegistry.SetPricing(modelID, models.Pricing{
+			BaseCost:          pricing.BaseCost,
+			PerSample:         pricing.PerSample,
+			PerStep:           pricing.PerStep,
+			UpscaleMultiplier: pricing.UpscaleMultiplier,
+		})
+		auditService.Record(systemCtx, models.AuditModelPricing, modelID, err)
+		if err != nil {
+			appLogger.Error("Invalid pricing configuration", err, "model_id", modelID)
+			os.Exit(1)
+		}
+	}
+	// Restrict and customize models per tenant
+	for tenantID, tenant := range cfg.Tenants {
+		err := modelRegistry.SetTenantOverrides(tenantID, models.ModelOverrides{
+			AllowedModels: tenant.AllowedModels,
+			Defaults:      tenant.Defaults,
+		})
+		auditService.Record(systemCtx, models.AuditModelTenantConfig, tenantID, err)
+		if err != nil {
+			appLogger.Error("Invalid tenant configuration", err, "tenant_id", tenantID)
+			os.Exit(1)
+		}
+	}
+	estimateService := estimate.NewService(modelRegistry, validator, usageRepository, appLogger)
+
+	generationService := generations.NewService(generationRepository, generator, appLogger)
+	apiKeyService := apikeys.NewService(
+		apiKeyRepository,
+		validator,
+		appLogger,
+		apikeys.WithBootstrapKey(cfg.Auth.BootstrapKey.Reveal()),
+		apikeys.WithAuditLog(auditService),
+		apikeys.WithRoles(policyRoles(cfg.RolePolicies)),
+	)
+	batchService := batch.NewService(
+		generator,
+		appLogger,
+		batch.WithMaxItems(cfg.Batch.MaxItems),
+		batch.WithMaxConcurrency(cfg.Batch.MaxConcurrency),
+	)
+	upstreamKeyService := upstreamkeys.NewService(keyPool, auditService, appLogger)
+	logLevelService := loglevel.NewService(appLogger, auditService, appLogger)
+	healthService := healthservice.NewService(
+		appLogger,
+		healthservice.WithProbeTimeout(cfg.Health.ProbeTimeout),
+		healthservice.WithProbes(readinessProbes(cfg, jobTracker, auditRepository)...),
+	)
+	sweepService := sweep.NewService(
+		batchService,
+		modelRegistry,
+		validator,
+		appLogger,
+		sweep.WithMaxCells(cfg.Sweep.MaxCells),
+		sweep.WithAccessPolicy(accessPolicy),
+	)
 
 	// Initialize handlers
 	handlers := make(map[string]ports.Handler)
-	handlers["models"] = modelshandler.NewHandler(modelRegistry, appLogger)
-	handlers["text2img"] = text2img.NewHandler(modelsLabService, appLogger)
-	handlers["health"] = health.NewHandler(appLogger)
+	handlers["models"] = modelshandler.NewHandler(modelRegistry, accessPolicy, appLogger)
+	handlers["text2img"] = text2img.NewHandler(generator, appLogger)
+	handlers["batch"] = batchhandler.NewHandler(batchService, appLogger)
+	handlers["sweep"] = sweephandler.NewHandler(sweepService, appLogger)
+	handlers["generation"] = generationshandler.NewHandler(generationService, appLogger)
+	handlers["reproduce"] = generationshandler.NewReproduceHandler(generationService, appLogger)
+	handlers["jobs"] = jobshandler.NewHandler(modelsLabService, appLogger)
+	handlers["cancel"] = jobshandler.NewCancelHandler(modelsLabService, appLogger)
+	handlers["apikeys"] = apikeyshandler.NewHandler(apiKeyService, appLogger)
+	handlers["revokekey"] = apikeyshandler.NewRevokeHandler(apiKeyService, appLogger)
+	handlers["usage"] = usagehandler.NewHandler(usageService, appLogger)
+	handlers["usageexport"] = usagehandler.NewExportHandler(usageService, appLogger)
+	handlers["tenantsusageexport"] = usagehandler.NewTenantsExportHandler(usageService, appLogger)
+	handlers["estimate"] = estimatehandler.NewHandler(estimateService, appLogger)
+	handlers["audit"] = audithandler.NewHandler(auditService, appLogger)
+	handlers["auditexport"] = audithandler.NewExportHandler(auditService, appLogger)
+	handlers["upstreamkeys"] = upstreamkeyshandler.NewHandler(upstreamKeyService, appLogger)
+	handlers["reloadupstreamkeys"] = upstreamkeyshandler.NewReloadHandler(upstreamKeyService, appLogger)
+	handlers["loglevel"] = loglevelhandler.NewHandler(logLevelService, appLogger)
+	handlers["health"] = health.NewHandler(healthService, appLogger)
+	handlers["ready"] = health.NewReadyHandler(healthService, appLogger)
 
 	// Create and configure server
-	server := app.NewServer(cfg, appLogger, handlers)
+	serverOpts := []app.ServerOption{app.WithAccessPolicy(accessPolicy)}
+	if cfg.Metrics.Enabled {
+		handlers["metrics"] = metricshandler.NewHandler(collector, appLogger)
+		serverOpts = append(serverOpts, app.WithMetrics(collector))
+	}
+	if cfg.RateLimit.Enabled {
+		serverOpts = append(serverOpts, app.WithRateLimits(cfg.RateLimit.Default, cfg.RateLimit.Routes))
+	}
+	if cfg.Auth.Enabled {
+		serverOpts = append(serverOpts, app.WithAPIKeyAuth(apiKeyService))
+
+		if cfg.Auth.JWT.JWKS != "" {
+			jwtAuthenticator, err := newJWTAuthenticator(cfg.Auth.JWT, appLogger)
+			if err != nil {
+				appLogger.Error("Invalid JWT configuration", err)
+				os.Exit(1)
+			}
+			serverOpts = append(serverOpts, app.WithJWTAuth(jwtAuthenticator))
+		}
+	} else {
+		appLogger.Info("API authentication is disabled; anyone who can reach the server can generate images")
+	}
+	server := app.NewServer(cfg, appLogger, handlers, serverOpts...)
+
+	// Reload the ModelsLab API keys on SIGHUP
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			upstreamKeyService.Reload(systemCtx)
+		}
+	}()
 
 	// Handle graceful shutdown
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
+	// Pick up generations that were still processing upstream when the server last stopped
+	modelsLabService.ResumePending(context.Background(), usageService, generator)
+
 	go func() {
 		if err := server.Start(); err != nil {
 			appLogger.Error("Server failed", err)
@@ -73,10 +368,15 @@ func main() {
 
 	// Wait for interrupt signal
 	<-done
-	appLogger.Info("Shutting down server...")
+	appLogger.Info("Shutting down server...", "drain_timeout", cfg.Server.DrainTimeout.String())
+
+	// Refuse new generations, which also fails readiness, and let running ones finish
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Server.DrainTimeout)
+	modelsLabService.Drain(drainCtx)
+	cancelDrain()
 
 	// Create shutdown context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Attempt graceful shutdown
@@ -85,5 +385,105 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Flush spans that are still buffered
+	if err := shutdownTracing(ctx); err != nil {
+		appLogger.Error("Failed to flush traces", err)
+	}
+
 	appLogger.Info("Server stopped gracefully")
 }
+
+// readinessProbes returns the probes checked for readiness: shutdown, ModelsLab, the audit
+// log's hash chain, every directory holding persisted records, and the free space of the
+// first of them
+func readinessProbes(cfg *config.Config, drainer probes.Drainer, auditLog probes.Verifier) []ports.HealthProbe {
+	probeList := []ports.HealthProbe{
+		probes.NewShutdownProbe(drainer),
+		probes.NewModelsLabProbe(cfg.ModelsLab.BaseURL, cfg.Health.ModelsLabCacheTTL),
+		probes.NewAuditLogProbe(auditLog),
+	}
+
+	var dirs []string
+	seen := make(map[string]bool)
+	for _, path := range []string{cfg.Auth.KeysFile, cfg.Usage.File, cfg.Audit.File, cfg.Storage.PendingJobsFile} {
+		if path == "" {
+			continue
+		}
+		if dir := filepath.Dir(path); !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+			probeList = append(probeList, probes.NewWritableDirProbe("store:"+dir, dir))
+		}
+	}
+
+	if len(dirs) > 0 {
+		probeList = append(probeList, probes.NewDiskSpaceProbe(dirs[0], cfg.Health.MinFreeDisk))
+	}
+
+	return probeList
+}
+
+// newJWTAuthenticator builds the JWT authenticator and loads the identity provider's keys.
+// A provider that is unreachable at startup is retried when the first token arrives.
+func newJWTAuthenticator(cfg config.JWTConfig, logger ports.Logger) (*auth.JWTAuthenticator, error) {
+	defaultScopes, err := parseScopes(cfg.DefaultScopes)
+	if err != nil {
+		return nil, err
+	}
+
+	roleScopes := make(map[string][]models.Scope, len(cfg.RoleScopes))
+	for role, values := range cfg.RoleScopes {
+		if roleScopes[role], err = parseScopes(values); err != nil {
+			return nil, err
+		}
+	}
+
+	keySet := auth.NewKeySet(cfg.JWKS, cfg.RefreshInterval, logger)
+	if err := keySet.Load(context.Background()); err != nil {
+		logger.Error("Failed to load JWKS", err, "source", cfg.JWKS)
+	}
+
+	return auth.NewJWTAuthenticator(keySet, auth.JWTConfig{
+		Issuer:        cfg.Issuer,
+		Audience:      cfg.Audience,
+		Leeway:        cfg.Leeway,
+		RolesClaim:    cfg.RolesClaim,
+		TenantClaim:   cfg.TenantClaim,
+		RoleScopes:    roleScopes,
+		DefaultScopes: defaultScopes,
+	}, logger), nil
+}
+
+// parseScopes converts configured scope names, rejecting unknown ones
+func parseScopes(values []string) ([]models.Scope, error) {
+	scopes := make([]models.Scope, 0, len(values))
+	for _, value := range values {
+		scope := models.Scope(value)
+		if !scope.IsValid() {
+			return nil, fmt.Errorf("unknown scope %q", value)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// requestLogFields adds the request ID and trace carried by a request's context to its log entries
+func requestLogFields(ctx context.Context) []interface{} {
+	var fields []interface{}
+	if id := models.RequestIDFromContext(ctx); id != "" {
+		fields = append(fields, "request_id", id)
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		fields = append(fields, "trace_id", span.TraceID().String(), "span_id", span.SpanID().String())
+	}
+	return fields
+}
+
+// policyRoles returns the roles that have an access policy, the only ones keys may be given
+func policyRoles(policies map[string]models.RolePolicy) []string {
+	roles := make([]string, 0, len(policies))
+	for role := range policies {
+		roles = append(roles, role)
+	}
+	return roles
+}
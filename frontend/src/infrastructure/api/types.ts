@@ -36,6 +36,7 @@ export interface ImageGenerationResponse {
   output?: string[];
   task_id?: string;
   progress?: number;
+  generation_id?: string;
   meta?: {
     prompt: string;
     model_id: string;
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+	apperrors "image/pkg/errors"
+)
+
+// operatorRoutes act on state shared by every tenant, such as the upstream key pool, the
+// process log level, and metrics and usage of all tenants, so only the operator may call them
+var operatorRoutes = map[string]bool{
+	"upstreamkeys":       true,
+	"reloadupstreamkeys": true,
+	"loglevel":           true,
+	"metrics":            true,
+	"tenantsusageexport": true,
+}
+
+// authMiddleware authenticates API requests and attaches the caller's principal and tenant to the context.
+// Bearer tokens shaped like JWTs go to the JWT authenticator, everything else is treated as an
+// API key. It is a no-op when no authenticator is configured.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authEnabled() || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		authenticator, credential := s.credential(r)
+		if authenticator == nil {
+			s.unauthorized(w, r, apperrors.NewUnauthorizedError("Missing credentials", nil))
+			return
+		}
+
+		principal, err := authenticator.Authenticate(r.Context(), credential)
+		if err != nil {
+			s.unauthorized(w, r, err)
+			return
+		}
+
+		ctx := models.WithPrincipal(r.Context(), principal)
+		ctx = models.WithTenant(ctx, principal.TenantID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireScope rejects requests whose principal was not granted the scope
+func (s *Server) requireScope(scope models.Scope, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authEnabled() || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := models.PrincipalFromContext(r.Context())
+		if !ok {
+			respond.Error(w, r, s.logger, apperrors.NewUnauthorizedError("Authentication required", nil))
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			respond.Error(w, r, s.logger, apperrors.NewForbiddenError(
+				fmt.Sprintf("Caller lacks the %s scope", scope),
+				nil,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireOperator rejects principals outside the default tenant, which the operator's own
+// keys and tokens belong to
+func (s *Server) requireOperator(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.authEnabled() && r.Method != http.MethodOptions && models.TenantFromContext(r.Context()) != models.DefaultTenantID {
+			respond.Error(w, r, s.logger, apperrors.NewForbiddenError(
+				fmt.Sprintf("Only operators of the %s tenant may call %s", models.DefaultTenantID, route),
+				nil,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireEndpoint rejects requests whose principal's roles may not call the route
+func (s *Server) requireEndpoint(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions && !s.access.AllowsEndpoint(r.Context(), route) {
+			respond.Error(w, r, s.logger, apperrors.NewForbiddenError(
+				fmt.Sprintf("Your role may not call %s", route),
+				nil,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authEnabled reports whether any authenticator is configured
+func (s *Server) authEnabled() bool {
+	return s.apiKeys != nil || s.jwt != nil
+}
+
+// credential picks the authenticator for the request and the credential to give it
+func (s *Server) credential(r *http.Request) (ports.Authenticator, string) {
+	if key := r.Header.Get("X-API-Key"); key != "" && s.apiKeys != nil {
+		return s.apiKeys, key
+	}
+
+	token := bearerToken(r)
+	if token == "" {
+		return nil, ""
+	}
+
+	if strings.Count(token, ".") == 2 {
+		if s.jwt != nil {
+			return s.jwt, token
+		}
+		return nil, ""
+	}
+
+	if s.apiKeys != nil {
+		return s.apiKeys, token
+	}
+
+	return nil, ""
+}
+
+// unauthorized logs a failed authentication and writes the error with a bearer challenge
+func (s *Server) unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	s.logger.InfoContext(r.Context(), "Authentication failed",
+		"path", r.URL.Path,
+		"remote_addr", r.RemoteAddr,
+	)
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	respond.Error(w, r, s.logger, err)
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header, if present
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+	return ""
+}
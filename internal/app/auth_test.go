@@ -0,0 +1,92 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/infrastructure/config"
+	apperrors "image/pkg/errors"
+	"image/pkg/logger"
+)
+
+// staticAuthenticator resolves API keys to fixed principals
+type staticAuthenticator map[string]*models.Principal
+
+func (a staticAuthenticator) Authenticate(ctx context.Context, credential string) (*models.Principal, error) {
+	if principal, ok := a[credential]; ok {
+		return principal, nil
+	}
+	return nil, apperrors.NewUnauthorizedError("Invalid API key", nil)
+}
+
+func newTestServer(t *testing.T, routes ...string) *Server {
+	t.Helper()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handlers := make(map[string]ports.Handler, len(routes))
+	for _, route := range routes {
+		handlers[route] = ok
+	}
+
+	cfg := &config.Config{Server: config.ServerConfig{
+		MaxBodyBytes:   1 << 10,
+		HandlerTimeout: time.Second,
+	}}
+
+	return NewServer(cfg, logger.New(logger.WithOutput(io.Discard)), handlers, WithAPIKeyAuth(staticAuthenticator{
+		"operator":   {ID: "ops", Kind: models.PrincipalAPIKey, TenantID: models.DefaultTenantID, Scopes: []models.Scope{models.ScopeAdmin}},
+		"acme-admin": {ID: "acme-ops", Kind: models.PrincipalAPIKey, TenantID: "acme", Scopes: []models.Scope{models.ScopeAdmin}},
+		"member":     {ID: "dev", Kind: models.PrincipalAPIKey, TenantID: models.DefaultTenantID, Scopes: []models.Scope{models.ScopeGenerate}},
+	}))
+}
+
+func TestOperatorRoutes(t *testing.T) {
+	server := newTestServer(t, "upstreamkeys", "reloadupstreamkeys", "loglevel", "metrics", "usage", "usageexport", "tenantsusageexport")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		want   int
+	}{
+		{"operator lists upstream keys", http.MethodGet, "/api/v6/upstream-keys", "operator", http.StatusOK},
+		{"tenant admin lists upstream keys", http.MethodGet, "/api/v6/upstream-keys", "acme-admin", http.StatusForbidden},
+		{"operator reloads upstream keys", http.MethodPost, "/api/v6/upstream-keys/reload", "operator", http.StatusOK},
+		{"tenant admin reloads upstream keys", http.MethodPost, "/api/v6/upstream-keys/reload", "acme-admin", http.StatusForbidden},
+		{"operator changes the log level", http.MethodPut, "/api/v6/log-level", "operator", http.StatusOK},
+		{"tenant admin changes the log level", http.MethodPut, "/api/v6/log-level", "acme-admin", http.StatusForbidden},
+		{"operator reads metrics", http.MethodGet, "/metrics", "operator", http.StatusOK},
+		{"tenant admin reads metrics", http.MethodGet, "/metrics", "acme-admin", http.StatusForbidden},
+		{"operator without the admin scope", http.MethodGet, "/metrics", "member", http.StatusForbidden},
+		{"anonymous caller", http.MethodGet, "/metrics", "", http.StatusUnauthorized},
+		{"operator exports usage of every tenant", http.MethodGet, "/api/v6/usage/tenants/export.csv", "operator", http.StatusOK},
+		{"tenant admin exports usage of every tenant", http.MethodGet, "/api/v6/usage/tenants/export.csv", "acme-admin", http.StatusForbidden},
+		{"tenant admin keeps tenant routes", http.MethodGet, "/api/v6/usage", "acme-admin", http.StatusOK},
+		{"tenant admin exports the tenant's usage", http.MethodGet, "/api/v6/usage/export.csv", "acme-admin", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+
+			server.router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
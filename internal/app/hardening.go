@@ -0,0 +1,247 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"image/internal/handlers/respond"
+	apperrors "image/pkg/errors"
+)
+
+// routeBodyLimit returns the request body limit of a route in bytes
+func (s *Server) routeBodyLimit(route string) int64 {
+	if limit, ok := s.limits.RouteMaxBodyBytes[route]; ok {
+		return limit
+	}
+	return s.limits.MaxBodyBytes
+}
+
+// routeTimeout returns how long a route's handler may run
+func (s *Server) routeTimeout(route string) time.Duration {
+	if timeout, ok := s.limits.RouteTimeouts[route]; ok {
+		return timeout
+	}
+	return s.limits.HandlerTimeout
+}
+
+// securityHeadersMiddleware sets security headers on every response. API responses and
+// exported files are never meant to be rendered as documents, so the content security
+// policy forbids loading anything from them or framing them.
+func (s *Server) securityHeadersMiddleware(next http.Handler) http.Handler {
+	var hsts string
+	if s.security.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(s.security.HSTSMaxAge.Seconds()))
+		if s.security.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		if hsts != "" {
+			w.Header().Set("Strict-Transport-Security", hsts)
+		}
+		if s.security.ContentSecurityPolicy != "" {
+			w.Header().Set("Content-Security-Policy", s.security.ContentSecurityPolicy)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyMiddleware rejects request bodies over limit and bodies that are not JSON
+func (s *Server) bodyMiddleware(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			respond.Error(w, r, s.logger, apperrors.NewPayloadTooLargeError(
+				fmt.Sprintf("Request body exceeds the limit of %d bytes", limit), nil))
+			return
+		}
+		// Checked before wrapping, which hides http.NoBody
+		hasBody := r.ContentLength != 0 && r.Body != nil && r.Body != http.NoBody
+
+		// Bodies without a declared length are cut off while they are decoded
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+		if hasBody && !isJSON(r.Header.Get("Content-Type")) {
+			respond.Error(w, r, s.logger, apperrors.NewUnsupportedMediaTypeError(
+				"Content-Type must be application/json", nil))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isJSON reports whether a Content-Type header names UTF-8 JSON
+func isJSON(contentType string) bool {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/json" {
+		return false
+	}
+	charset, ok := params["charset"]
+	return !ok || strings.EqualFold(charset, "utf-8")
+}
+
+// streamingRoutes write responses too large to buffer, so their handlers are bounded by a
+// deadline alone
+var streamingRoutes = map[string]bool{
+	"usageexport":        true,
+	"tenantsusageexport": true,
+	"auditexport":        true,
+}
+
+// routeTimeoutMiddleware bounds how long a route's handler may run
+func (s *Server) routeTimeoutMiddleware(route string, next http.Handler) http.Handler {
+	if streamingRoutes[route] {
+		return s.deadlineMiddleware(s.routeTimeout(route), next)
+	}
+	return s.timeoutMiddleware(s.routeTimeout(route), next)
+}
+
+// deadlineMiddleware cancels a handler's context after timeout and extends the write
+// deadline to match, without buffering the response. A handler that overruns ends with
+// a truncated response rather than a TIMEOUT error.
+func (s *Server) deadlineMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + s.server.WriteTimeout)); err != nil {
+			s.logger.DebugContext(r.Context(), "Failed to extend write deadline", "error", err.Error())
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// timeoutMiddleware bounds how long a handler may run. The handler's response is buffered
+// so that a handler still running at the deadline can be answered with a TIMEOUT error
+// instead of a reset connection; its context is cancelled at the same time.
+func (s *Server) timeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The server-wide write timeout would cut off routes allowed to run longer
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + s.server.WriteTimeout)); err != nil {
+			s.logger.DebugContext(r.Context(), "Failed to extend write deadline", "error", err.Error())
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{header: w.Header().Clone()}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+					return
+				}
+				close(done)
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		}()
+
+		select {
+		case p := <-panicked:
+			// Re-raise the panic where the recovery middleware can see it
+			panic(p)
+		case <-done:
+			tw.writeTo(w)
+		case <-ctx.Done():
+			tw.abandon()
+			s.logger.InfoContext(r.Context(), "Request timed out",
+				"path", r.URL.Path,
+				"timeout", timeout.String(),
+			)
+			respond.Error(w, r, s.logger, apperrors.NewTimeoutError(
+				fmt.Sprintf("Request did not complete within %s", timeout),
+				ctx.Err(),
+			))
+		}
+	})
+}
+
+// timeoutWriter buffers a handler's response until it finishes in time. Writes after the
+// deadline fail with http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu        sync.Mutex
+	header    http.Header
+	body      bytes.Buffer
+	status    int
+	abandoned bool
+}
+
+// Header implements http.ResponseWriter
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// WriteHeader implements http.ResponseWriter
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.abandoned || tw.status != 0 {
+		return
+	}
+	tw.status = status
+}
+
+// Write implements http.ResponseWriter
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.abandoned {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.body.Write(b)
+}
+
+// abandon discards the response once the deadline has passed
+func (tw *timeoutWriter) abandon() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.abandoned = true
+}
+
+// writeTo writes the buffered response to w
+func (tw *timeoutWriter) writeTo(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := w.Header()
+	for key := range dst {
+		if _, ok := tw.header[key]; !ok {
+			delete(dst, key)
+		}
+	}
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	w.WriteHeader(tw.status)
+	_, _ = w.Write(tw.body.Bytes())
+}
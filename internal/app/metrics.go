@@ -0,0 +1,55 @@
+package app
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// metricsMiddleware records the count and latency of every request by route template,
+// method and status code
+func (s *Server) metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		s.metrics.ObserveRequest(routeTemplate(r), r.Method, recorder.status, time.Since(start))
+	})
+}
+
+// routeTemplate returns the path template of the matched route, keeping IDs out of labels.
+// Router middleware only runs for matched routes, all of which have a path.
+func routeTemplate(r *http.Request) string {
+	template, _ := mux.CurrentRoute(r).GetPathTemplate()
+	return template
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer, letting http.ResponseController reach it
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"image/internal/handlers/respond"
+	"image/internal/infrastructure/ratelimit"
+	apperrors "image/pkg/errors"
+)
+
+// routeLimiter returns the limiter for a route, falling back to the default limiter
+func (s *Server) routeLimiter(route string) *ratelimit.Limiter {
+	if limiter, ok := s.routeLimits[route]; ok {
+		return limiter
+	}
+	return s.defaultLimit
+}
+
+// rateLimitMiddleware rejects callers that exhausted their token bucket and reports the
+// bucket state in RateLimit-* headers on every response
+func (s *Server) rateLimitMiddleware(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := ratelimit.KeyFromContext(r.Context())
+		result := limiter.Allow(key)
+
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			s.logger.InfoContext(r.Context(), "Rate limit exceeded",
+				"limit_key", key,
+				"path", r.URL.Path,
+				"retry_after", result.RetryAfter,
+			)
+			respond.Error(w, r, s.logger, apperrors.NewRateLimitedError(
+				"Rate limit exceeded, retry later",
+				result.RetryAfter,
+			))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -2,25 +2,97 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
+	"image/internal/domain/models"
 	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
 	"image/internal/infrastructure/config"
+	"image/internal/infrastructure/cors"
+	"image/internal/infrastructure/ratelimit"
+	apperrors "image/pkg/errors"
+	"image/pkg/ids"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	// requestIDHeader carries the request ID from and back to callers
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds caller-supplied request IDs
+	maxRequestIDLength = 128
+)
+
 // Server represents the HTTP server
 type Server struct {
-	server *http.Server
-	router *mux.Router
-	logger ports.Logger
+	server  *http.Server
+	router  *mux.Router
+	logger  ports.Logger
+	apiKeys ports.Authenticator
+	jwt     ports.Authenticator
+	access  ports.AccessPolicy
+	metrics ports.Metrics
+	// routeLimits holds per-route rate limiters, with defaultLimit applied to other routes
+	routeLimits  map[string]*ratelimit.Limiter
+	defaultLimit *ratelimit.Limiter
+	// cors decides which browser origins may call the API and what they may send
+	cors *cors.Policy
+	// limits holds the request body limits and handler timeouts of routes
+	limits   config.ServerConfig
+	security config.SecurityConfig
+}
+
+// ServerOption defines a function type for server configuration
+type ServerOption func(*Server)
+
+// WithAPIKeyAuth requires every API request to present a key accepted by the authenticator
+func WithAPIKeyAuth(authenticator ports.Authenticator) ServerOption {
+	return func(s *Server) {
+		s.apiKeys = authenticator
+	}
+}
+
+// WithJWTAuth accepts bearer JWTs verified by the authenticator, alongside API keys
+func WithJWTAuth(authenticator ports.Authenticator) ServerOption {
+	return func(s *Server) {
+		s.jwt = authenticator
+	}
+}
+
+// WithAccessPolicy rejects requests to routes the caller's roles may not call
+func WithAccessPolicy(access ports.AccessPolicy) ServerOption {
+	return func(s *Server) {
+		s.access = access
+	}
+}
+
+// WithMetrics records the count and latency of every request
+func WithMetrics(metrics ports.Metrics) ServerOption {
+	return func(s *Server) {
+		s.metrics = metrics
+	}
+}
+
+// WithRateLimits limits API requests per caller, using the route's rule when one is
+// configured and the default rule otherwise. A nil default leaves other routes unlimited.
+func WithRateLimits(defaultRule *ratelimit.Rule, routeRules map[string]ratelimit.Rule) ServerOption {
+	return func(s *Server) {
+		if defaultRule != nil {
+			s.defaultLimit = ratelimit.NewLimiter(*defaultRule)
+		}
+		s.routeLimits = make(map[string]*ratelimit.Limiter, len(routeRules))
+		for route, rule := range routeRules {
+			s.routeLimits[route] = ratelimit.NewLimiter(rule)
+		}
+	}
 }
 
 // NewServer creates a new server instance
-func NewServer(cfg *config.Config, logger ports.Logger, handlers map[string]ports.Handler) *Server {
+func NewServer(cfg *config.Config, logger ports.Logger, handlers map[string]ports.Handler, opts ...ServerOption) *Server {
 	router := mux.NewRouter()
 
 	// Create server instance
@@ -31,8 +103,15 @@ func NewServer(cfg *config.Config, logger ports.Logger, handlers map[string]port
 			ReadTimeout:  cfg.Server.ReadTimeout,
 			WriteTimeout: cfg.Server.WriteTimeout,
 		},
-		router: router,
-		logger: logger,
+		router:   router,
+		logger:   logger,
+		cors:     cors.NewPolicy(cfg.CORS),
+		limits:   cfg.Server,
+		security: cfg.Security,
+	}
+
+	for _, opt := range opts {
+		opt(srv)
 	}
 
 	// Setup routes
@@ -44,74 +123,268 @@ func NewServer(cfg *config.Config, logger ports.Logger, handlers map[string]port
 // setupRoutes configures the server routes
 func (s *Server) setupRoutes(handlers map[string]ports.Handler) {
 	// Add middleware to all routes - order matters!
-	s.router.Use(s.corsMiddleware) // CORS headers must be first
+	s.router.Use(s.securityHeadersMiddleware)
+	s.router.Use(s.corsMiddleware) // CORS headers must come before anything that can respond
+	s.router.Use(s.requestContextMiddleware)
+	if s.metrics != nil {
+		s.router.Use(s.metricsMiddleware)
+	}
+	s.router.Use(s.tracingMiddleware)
 	s.router.Use(s.loggingMiddleware)
 	s.router.Use(s.recoveryMiddleware)
 
 	// API routes
 	api := s.router.PathPrefix("/api/v6").Subrouter()
+	api.Use(s.authMiddleware)
 
 	// Models endpoint
 	if h, ok := handlers["models"]; ok {
-		api.Handle("/models", s.middleware(h)).Methods(http.MethodGet, http.MethodOptions)
+		api.Handle("/models", s.middleware("models", h)).Name("models").Methods(http.MethodGet, http.MethodOptions)
 	}
 
 	// Text to Image endpoint
 	if h, ok := handlers["text2img"]; ok {
-		api.Handle("/images/text2img", s.middleware(h)).Methods(http.MethodPost, http.MethodOptions)
+		api.Handle("/images/text2img", s.middleware("text2img", h, models.ScopeGenerate)).Name("text2img").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// Batch generation endpoint
+	if h, ok := handlers["batch"]; ok {
+		api.Handle("/images/batch", s.middleware("batch", h, models.ScopeGenerate)).Name("batch").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// Parameter sweep endpoint
+	if h, ok := handlers["sweep"]; ok {
+		api.Handle("/images/sweep", s.middleware("sweep", h, models.ScopeGenerate)).Name("sweep").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// Cost estimation endpoint
+	if h, ok := handlers["estimate"]; ok {
+		api.Handle("/images/estimate", s.middleware("estimate", h, models.ScopeGenerate)).Name("estimate").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// In-flight generation endpoints
+	if h, ok := handlers["jobs"]; ok {
+		api.Handle("/images/jobs", s.middleware("jobs", h, models.ScopeReadHistory)).Name("jobs").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	if h, ok := handlers["cancel"]; ok {
+		api.Handle("/images/{id}/cancel", s.middleware("cancel", h, models.ScopeGenerate)).Name("cancel").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// Generation history endpoints
+	if h, ok := handlers["generation"]; ok {
+		api.Handle("/generations/{id}", s.middleware("generation", h, models.ScopeReadHistory)).Name("generation").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	if h, ok := handlers["reproduce"]; ok {
+		api.Handle("/generations/{id}/reproduce", s.middleware("reproduce", h, models.ScopeGenerate)).Name("reproduce").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// API key management endpoints
+	if h, ok := handlers["apikeys"]; ok {
+		api.Handle("/keys", s.middleware("apikeys", h, models.ScopeAdmin)).Name("apikeys").Methods(http.MethodGet, http.MethodPost, http.MethodOptions)
+	}
+
+	if h, ok := handlers["revokekey"]; ok {
+		api.Handle("/keys/{id}", s.middleware("revokekey", h, models.ScopeAdmin)).Name("revokekey").Methods(http.MethodDelete, http.MethodOptions)
+	}
+
+	// Usage reporting endpoints
+	if h, ok := handlers["usage"]; ok {
+		api.Handle("/usage", s.middleware("usage", h, models.ScopeReadHistory)).Name("usage").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	if h, ok := handlers["usageexport"]; ok {
+		api.Handle("/usage/export.csv", s.middleware("usageexport", h, models.ScopeAdmin)).Name("usageexport").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	if h, ok := handlers["tenantsusageexport"]; ok {
+		api.Handle("/usage/tenants/export.csv", s.middleware("tenantsusageexport", h, models.ScopeAdmin)).Name("tenantsusageexport").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	// Audit log endpoints
+	if h, ok := handlers["audit"]; ok {
+		api.Handle("/audit", s.middleware("audit", h, models.ScopeAdmin)).Name("audit").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	if h, ok := handlers["auditexport"]; ok {
+		api.Handle("/audit/export.jsonl", s.middleware("auditexport", h, models.ScopeAdmin)).Name("auditexport").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	// Upstream key pool endpoints
+	if h, ok := handlers["upstreamkeys"]; ok {
+		api.Handle("/upstream-keys", s.middleware("upstreamkeys", h, models.ScopeAdmin)).Name("upstreamkeys").Methods(http.MethodGet, http.MethodOptions)
+	}
+
+	if h, ok := handlers["reloadupstreamkeys"]; ok {
+		api.Handle("/upstream-keys/reload", s.middleware("reloadupstreamkeys", h, models.ScopeAdmin)).Name("reloadupstreamkeys").Methods(http.MethodPost, http.MethodOptions)
+	}
+
+	// Log level endpoint
+	if h, ok := handlers["loglevel"]; ok {
+		api.Handle("/log-level", s.middleware("loglevel", h, models.ScopeAdmin)).Name("loglevel").Methods(http.MethodGet, http.MethodPut, http.MethodOptions)
 	}
 
-	// Health check endpoint
+	// Health check endpoints; /health is kept as an alias of the liveness probe
 	if h, ok := handlers["health"]; ok {
 		s.router.Handle("/health", h).Methods(http.MethodGet)
+		s.router.Handle("/health/live", h).Methods(http.MethodGet)
+	}
+
+	if h, ok := handlers["ready"]; ok {
+		s.router.Handle("/health/ready", h).Methods(http.MethodGet)
+	}
+
+	// Prometheus metrics endpoint; it reveals routes, models and traffic, so it is kept to operators
+	if h, ok := handlers["metrics"]; ok {
+		s.router.Handle("/metrics", s.authMiddleware(s.middleware("metrics", h, models.ScopeAdmin))).Name("metrics").Methods(http.MethodGet)
 	}
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP server, returning nil once it has been shut down
 func (s *Server) Start() error {
 	s.logger.Info("Starting server", "addr", s.server.Addr)
-	return s.server.ListenAndServe()
+	if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // Shutdown gracefully shuts down the server
 func (s *Server) Shutdown(ctx context.Context) error {
-	s.logger.Info("Shutting down server")
+	s.logger.InfoContext(ctx, "Shutting down server")
 	return s.server.Shutdown(ctx)
 }
 
-// middleware wraps a handler with common middleware
-func (s *Server) middleware(handler http.Handler) http.Handler {
+// middleware wraps a named route's handler with common middleware, requiring the given scope when set
+func (s *Server) middleware(route string, handler http.Handler, scopes ...models.Scope) http.Handler {
+	handler = s.routeTimeoutMiddleware(route, handler)
+
+	if s.access != nil {
+		handler = s.requireEndpoint(route, handler)
+	}
+
+	if operatorRoutes[route] {
+		handler = s.requireOperator(route, handler)
+	}
+
+	if len(scopes) > 0 {
+		handler = s.requireScope(scopes[0], handler)
+	}
+
+	if limiter := s.routeLimiter(route); limiter != nil {
+		handler = s.rateLimitMiddleware(limiter, handler)
+	}
+
+	handler = s.bodyMiddleware(s.routeBodyLimit(route), handler)
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		handler.ServeHTTP(w, r)
 	})
 }
 
-// corsMiddleware handles CORS headers for all routes
+// corsMiddleware applies the CORS policy. Responses to allowed origins echo the origin;
+// preflights are answered here with what the matched route accepts, and rejected with 403
+// when the origin, method or headers are not allowed.
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Add CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:5173")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		// Handle preflight requests
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+
 		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
+			s.preflight(w, r, origin)
 			return
 		}
 
+		if s.cors.AllowsOrigin(origin) {
+			s.cors.SetResponseHeaders(w.Header(), origin)
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
 
+// preflight answers an OPTIONS request. Requests that are not CORS preflights get an
+// empty response without CORS headers.
+func (s *Server) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	method := r.Header.Get("Access-Control-Request-Method")
+	if origin == "" || method == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	if !s.cors.AllowsOrigin(origin) {
+		respond.Error(w, r, s.logger, apperrors.NewForbiddenError(
+			fmt.Sprintf("Origin %s is not allowed", origin), nil))
+		return
+	}
+
+	var route string
+	if current := mux.CurrentRoute(r); current != nil {
+		route = current.GetName()
+	}
+	rule := s.cors.Rule(route)
+
+	if !rule.AllowsMethod(method) {
+		respond.Error(w, r, s.logger, apperrors.NewForbiddenError(
+			fmt.Sprintf("Method %s is not allowed from origin %s", method, origin), nil))
+		return
+	}
+	if headers := r.Header.Get("Access-Control-Request-Headers"); !rule.AllowsHeaders(headers) {
+		respond.Error(w, r, s.logger, apperrors.NewForbiddenError(
+			fmt.Sprintf("Headers %s are not allowed from origin %s", headers, origin), nil))
+		return
+	}
+
+	s.cors.SetPreflightHeaders(w.Header(), origin, rule)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestContextMiddleware attaches request metadata such as the client IP and request ID to
+// the context. The caller's X-Request-ID is kept when valid, otherwise one is generated, and
+// it is echoed in the response.
+func (s *Server) requestContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		requestID := r.Header.Get(requestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = ids.New("req")
+		}
+		w.Header().Set(requestIDHeader, requestID)
+
+		ctx := models.WithClientIP(r.Context(), ip)
+		ctx = models.WithRequestID(ctx, requestID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether a caller-supplied request ID is safe to log and forward:
+// non-empty, bounded in length and limited to printable ASCII without spaces
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 // loggingMiddleware logs incoming requests
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		s.logger.Info("Request started",
+		s.logger.InfoContext(r.Context(), "Request started",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"remote_addr", r.RemoteAddr,
@@ -119,7 +392,7 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 
 		next.ServeHTTP(w, r)
 
-		s.logger.Info("Request completed",
+		s.logger.InfoContext(r.Context(), "Request completed",
 			"method", r.Method,
 			"path", r.URL.Path,
 			"duration", time.Since(start),
@@ -132,7 +405,7 @@ func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				s.logger.Error("Panic recovered", fmt.Errorf("%v", err))
+				s.logger.ErrorContext(r.Context(), "Panic recovered", fmt.Errorf("%v", err))
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
 			}
 		}()
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// APIKey represents an API key issued to one of our clients. Only the hash of the secret is stored.
+type APIKey struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	TenantID  string     `json:"tenant_id"`
+	Prefix    string     `json:"prefix"`
+	Roles     []string   `json:"roles,omitempty"`
+	Hash      string     `json:"hash,omitempty"`
+	Scopes    []Scope    `json:"scopes"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsRevoked reports whether the key has been revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// Tenant returns the tenant the key belongs to; keys minted before tenants existed
+// belong to the default tenant
+func (k *APIKey) Tenant() string {
+	if k.TenantID == "" {
+		return DefaultTenantID
+	}
+	return k.TenantID
+}
+
+// Public returns a copy of the key that is safe to return from the API
+func (k APIKey) Public() APIKey {
+	k.Hash = ""
+	return k
+}
+
+// Principal returns the principal authenticated by the key
+func (k *APIKey) Principal() *Principal {
+	return &Principal{
+		ID:       k.ID,
+		Name:     k.Name,
+		Kind:     PrincipalAPIKey,
+		TenantID: k.Tenant(),
+		Roles:    k.Roles,
+		Scopes:   k.Scopes,
+	}
+}
+
+// CreateAPIKeyRequest represents a request to mint a new API key
+type CreateAPIKeyRequest struct {
+	Name   string  `json:"name" validate:"required,max=100"`
+	Scopes []Scope `json:"scopes" validate:"required,min=1"`
+	// Roles select the role policies that restrict what the key may generate
+	Roles []string `json:"roles,omitempty" validate:"omitempty,dive,required,max=64"`
+	// TenantID mints the key for another tenant; only admins of the default tenant may set it
+	TenantID string `json:"tenant_id,omitempty" validate:"omitempty,max=64"`
+}
+
+// CreateAPIKeyResponse represents a newly minted key; Key is only ever returned here
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeysResponse represents the response for the API key listing endpoint
+type APIKeysResponse struct {
+	Keys []APIKey `json:"keys"`
+}
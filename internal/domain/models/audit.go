@@ -0,0 +1,93 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// SystemPrincipalID identifies actions the server takes on its own, such as applying configuration
+const SystemPrincipalID = "system"
+
+// Audited actions
+const (
+	AuditGenerationCreate  = "generation.create"
+	AuditGenerationResume  = "generation.resume"
+	AuditAPIKeyCreate      = "apikey.create"
+	AuditAPIKeyRevoke      = "apikey.revoke"
+	AuditModelRegister     = "model.register"
+	AuditModelPricing      = "model.pricing"
+	AuditModelTenantConfig = "model.tenant_overrides"
+	AuditConfigLoad        = "config.load"
+	AuditConfigReload      = "config.reload"
+	AuditConfigLogLevel    = "config.log_level"
+)
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEvent records who did what to which target. Events form a hash chain: each event's
+// Hash covers its content and the Hash of the previous event, so edits and deletions are detectable.
+type AuditEvent struct {
+	Sequence  int64     `json:"sequence"`
+	TenantID  string    `json:"tenant_id"`
+	ActorID   string    `json:"actor_id"`
+	ActorName string    `json:"actor_name"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target"`
+	RequestID string    `json:"request_id,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	Outcome   string    `json:"outcome"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// AuditFilter narrows audit queries; zero values match everything
+type AuditFilter struct {
+	ActorID string
+	Action  string
+	Target  string
+	From    time.Time
+	To      time.Time
+}
+
+// Matches reports whether the event satisfies the filter
+func (f AuditFilter) Matches(event *AuditEvent) bool {
+	if f.ActorID != "" && event.ActorID != f.ActorID {
+		return false
+	}
+	if f.Action != "" && event.Action != f.Action {
+		return false
+	}
+	if f.Target != "" && event.Target != f.Target {
+		return false
+	}
+	if !f.From.IsZero() && event.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !event.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// AuditLogResponse represents the response for the audit log endpoint
+type AuditLogResponse struct {
+	// ChainValid reports whether the whole log verified against its hash chain
+	ChainValid bool         `json:"chain_valid"`
+	Events     []AuditEvent `json:"events"`
+}
+
+// SystemContext returns a copy of ctx acting as the server itself
+func SystemContext(ctx context.Context) context.Context {
+	return WithPrincipal(ctx, &Principal{
+		ID:       SystemPrincipalID,
+		Name:     SystemPrincipalID,
+		TenantID: DefaultTenantID,
+		Scopes:   []Scope{ScopeAdmin},
+	})
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"math"
+
+	apperrors "image/pkg/errors"
+)
+
+// Batch item statuses
+const (
+	BatchStatusSuccess = "success"
+	BatchStatusPartial = "partial"
+	BatchStatusError   = "error"
+)
+
+// BatchRequest represents a request to generate several images in one call.
+// Either Items is set, or Base is expanded over Prompts and SeedRange.
+type BatchRequest struct {
+	Items     []Text2ImgRequest `json:"items,omitempty"`
+	Base      *Text2ImgRequest  `json:"base,omitempty"`
+	Prompts   []string          `json:"prompts,omitempty"`
+	SeedRange *SeedRange        `json:"seed_range,omitempty"`
+}
+
+// SeedRange describes a contiguous range of seeds starting at Start
+type SeedRange struct {
+	Start int64 `json:"start"`
+	Count int   `json:"count"`
+}
+
+// ItemCount returns the number of requests the batch expands to, saturating at
+// math.MaxInt32 so it can be checked against limits before anything is allocated
+func (b *BatchRequest) ItemCount() int {
+	if len(b.Items) > 0 {
+		return len(b.Items)
+	}
+
+	prompts := len(b.Prompts)
+	if prompts == 0 {
+		prompts = 1
+	}
+	seeds := 1
+	if b.SeedRange != nil {
+		seeds = b.SeedRange.Count
+	}
+
+	if seeds > 0 && prompts > math.MaxInt32/seeds {
+		return math.MaxInt32
+	}
+	return prompts * seeds
+}
+
+// Expand returns the individual text-to-image requests described by the batch
+func (b *BatchRequest) Expand() ([]Text2ImgRequest, error) {
+	if len(b.Items) > 0 {
+		if b.Base != nil || len(b.Prompts) > 0 || b.SeedRange != nil {
+			return nil, apperrors.NewInvalidRequestError(
+				"items cannot be combined with base, prompts or seed_range",
+				nil,
+			)
+		}
+		return b.Items, nil
+	}
+
+	if b.Base == nil {
+		return nil, apperrors.NewInvalidRequestError("Either items or base is required", nil)
+	}
+
+	prompts := b.Prompts
+	if len(prompts) == 0 {
+		prompts = []string{b.Base.Prompt}
+	}
+
+	var seeds []*int64
+	if b.SeedRange != nil {
+		if b.SeedRange.Count < 1 {
+			return nil, apperrors.NewInvalidRequestError("seed_range count must be at least 1", nil)
+		}
+		for i := 0; i < b.SeedRange.Count; i++ {
+			seed := b.SeedRange.Start + int64(i)
+			seeds = append(seeds, &seed)
+		}
+	} else {
+		seeds = []*int64{b.Base.Seed}
+	}
+
+	items := make([]Text2ImgRequest, 0, len(prompts)*len(seeds))
+	for _, prompt := range prompts {
+		for _, seed := range seeds {
+			item := *b.Base
+			item.Prompt = prompt
+			item.Seed = seed
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// BatchItemResult represents the outcome of a single item in a batch
+type BatchItemResult struct {
+	Index          int            `json:"index"`
+	Status         string         `json:"status"`
+	Prompt         string         `json:"prompt"`
+	Seed           *int64         `json:"seed,omitempty"`
+	Output         []string       `json:"output,omitempty"`
+	GenerationTime float64        `json:"generation_time,omitempty"`
+	ID             int64          `json:"id,omitempty"`
+	Error          *ErrorResponse `json:"error,omitempty"`
+}
+
+// BatchResponse represents the response from the batch endpoint
+type BatchResponse struct {
+	Status    string            `json:"status"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Items     []BatchItemResult `json:"items"`
+}
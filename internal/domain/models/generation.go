@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Generation records a completed generation so it can be looked up and reproduced later
+type Generation struct {
+	ID        string            `json:"id"`
+	TenantID  string            `json:"tenant_id"`
+	Request   Text2ImgRequest   `json:"request"`
+	Response  *Text2ImgResponse `json:"response"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// ReproduceRequest returns the exact request needed to replay the generation,
+// pinned to the seed the upstream reported using
+func (g *Generation) ReproduceRequest() Text2ImgRequest {
+	req := g.Request
+	req.TrackID = ""
+	req.Webhook = ""
+
+	if g.Response != nil && g.Response.Meta != nil {
+		seed := g.Response.Meta.Seed
+		req.Seed = &seed
+	}
+
+	return req
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Health statuses
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthFailed   = "failed"
+)
+
+// HealthCheck reports the result of a single dependency probe
+type HealthCheck struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// HealthReport represents the response of the health endpoints
+type HealthReport struct {
+	Status    string        `json:"status"`
+	Timestamp time.Time     `json:"timestamp"`
+	Version   string        `json:"version"`
+	Commit    string        `json:"commit"`
+	Checks    []HealthCheck `json:"checks,omitempty"`
+}
+
+// IsHealthy reports whether every check passed
+func (r *HealthReport) IsHealthy() bool {
+	return r.Status == HealthOK
+}
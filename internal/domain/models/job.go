@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Job statuses
+const (
+	JobStatusRunning    = "running"
+	JobStatusProcessing = "processing"
+	JobStatusCancelled  = "cancelled"
+	JobStatusAbandoned  = "abandoned"
+)
+
+// Job represents an in-flight generation that can be inspected or cancelled
+type Job struct {
+	ID       string `json:"id"`
+	TenantID string `json:"tenant_id"`
+	// PrincipalID identifies the caller that started the job, who may cancel it
+	PrincipalID string `json:"principal_id"`
+	ModelID     string `json:"model_id"`
+	Status      string `json:"status"`
+	UpstreamID  int64  `json:"upstream_id,omitempty"`
+	// UpstreamKey names the pooled key that created the upstream job
+	UpstreamKey string    `json:"-"`
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// JobsResponse represents the response for the jobs endpoint
+type JobsResponse struct {
+	Jobs []Job `json:"jobs"`
+}
+
+// PendingJob is a generation still processing upstream, kept with its originating request
+// and caller so polling can resume, and the result be recorded and billed, if the server
+// restarts before it completes
+type PendingJob struct {
+	GenerationID  string          `json:"generation_id"`
+	TenantID      string          `json:"tenant_id"`
+	PrincipalID   string          `json:"principal_id"`
+	PrincipalName string          `json:"principal_name"`
+	RequestID     string          `json:"request_id,omitempty"`
+	ModelID       string          `json:"model_id"`
+	UpstreamID    int64           `json:"upstream_id"`
+	UpstreamKey   string          `json:"upstream_key,omitempty"`
+	Request       Text2ImgRequest `json:"request"`
+	StartedAt     time.Time       `json:"started_at"`
+}
+
+// Principal returns the caller that started the job
+func (j *PendingJob) Principal() *Principal {
+	if j.PrincipalID == "" {
+		return &Principal{ID: AnonymousPrincipalID, Name: AnonymousPrincipalID, TenantID: j.TenantID}
+	}
+	return &Principal{ID: j.PrincipalID, Name: j.PrincipalName, TenantID: j.TenantID}
+}
@@ -0,0 +1,6 @@
+package models
+
+// LogLevel represents the server's minimum log level: debug, info, warn or error
+type LogLevel struct {
+	Level string `json:"level"`
+}
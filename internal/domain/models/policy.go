@@ -0,0 +1,75 @@
+package models
+
+import (
+	"fmt"
+
+	apperrors "image/pkg/errors"
+)
+
+// DefaultRole is the role whose policy applies to principals none of whose roles has a policy
+const DefaultRole = "default"
+
+// RolePolicy decides which models, capabilities and endpoints a role may use
+type RolePolicy struct {
+	// Models lists the model IDs the role may use; empty allows every model
+	Models []string `json:"models,omitempty"`
+	// Endpoints lists the routes the role may call, e.g. text2img or batch; empty allows every route
+	Endpoints []string `json:"endpoints,omitempty"`
+	// AllowUpscale permits requests that upscale their output
+	AllowUpscale bool `json:"allow_upscale"`
+	// MaxSamples caps the samples per request; zero leaves the model's limit
+	MaxSamples int `json:"max_samples,omitempty"`
+	// MaxWidth and MaxHeight cap the resolution; zero leaves the model's limits
+	MaxWidth  int `json:"max_width,omitempty"`
+	MaxHeight int `json:"max_height,omitempty"`
+}
+
+// AllowsModel reports whether the role may use the model
+func (p RolePolicy) AllowsModel(modelID string) bool {
+	return len(p.Models) == 0 || containsString(p.Models, modelID)
+}
+
+// AllowsEndpoint reports whether the role may call the route
+func (p RolePolicy) AllowsEndpoint(route string) bool {
+	return len(p.Endpoints) == 0 || containsString(p.Endpoints, route)
+}
+
+// Check returns a forbidden error when the role may not make the request
+func (p RolePolicy) Check(req *Text2ImgRequest) error {
+	if !p.AllowsModel(req.ModelID) {
+		return apperrors.NewForbiddenError(
+			fmt.Sprintf("Model %s is not available to your role", req.ModelID),
+			nil,
+		)
+	}
+
+	if req.UpscaleFactor() > 1 && !p.AllowUpscale {
+		return apperrors.NewForbiddenError("Upscaling is not available to your role", nil)
+	}
+
+	if p.MaxSamples > 0 && req.Samples > p.MaxSamples {
+		return apperrors.NewForbiddenError(
+			fmt.Sprintf("Your role may request at most %d samples", p.MaxSamples),
+			nil,
+		)
+	}
+
+	if (p.MaxWidth > 0 && req.Width > p.MaxWidth) || (p.MaxHeight > 0 && req.Height > p.MaxHeight) {
+		return apperrors.NewForbiddenError(
+			fmt.Sprintf("Resolution %dx%d is not available to your role", req.Width, req.Height),
+			nil,
+		)
+	}
+
+	return nil
+}
+
+// containsString reports whether values contains target
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
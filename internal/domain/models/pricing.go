@@ -0,0 +1,56 @@
+package models
+
+import "math"
+
+// Pricing defines how many credits a generation with a model costs
+type Pricing struct {
+	// BaseCost is charged once per request
+	BaseCost float64 `json:"base_cost"`
+	// PerSample is charged for every sample
+	PerSample float64 `json:"per_sample"`
+	// PerStep is charged for every inference step of every sample
+	PerStep float64 `json:"per_step"`
+	// UpscaleMultiplier scales the total once per upscale level
+	UpscaleMultiplier float64 `json:"upscale_multiplier"`
+}
+
+// Estimate returns the credits a request costs and the breakdown of the charge
+func (p Pricing) Estimate(req *Text2ImgRequest) CostBreakdown {
+	breakdown := CostBreakdown{
+		Base:              p.BaseCost,
+		Samples:           p.PerSample * float64(req.Samples),
+		Steps:             p.PerStep * float64(req.NumInferenceSteps*req.Samples),
+		UpscaleMultiplier: 1,
+	}
+
+	if levels := req.UpscaleFactor() - 1; levels > 0 && p.UpscaleMultiplier > 0 {
+		breakdown.UpscaleMultiplier = math.Pow(p.UpscaleMultiplier, float64(levels))
+	}
+
+	breakdown.Total = (breakdown.Base + breakdown.Samples + breakdown.Steps) * breakdown.UpscaleMultiplier
+	return breakdown
+}
+
+// CostBreakdown itemizes an estimated charge
+type CostBreakdown struct {
+	Base              float64 `json:"base"`
+	Samples           float64 `json:"samples"`
+	Steps             float64 `json:"steps"`
+	UpscaleMultiplier float64 `json:"upscale_multiplier"`
+	Total             float64 `json:"total"`
+}
+
+// DurationEstimate describes expected generation time from historical runs of a model
+type DurationEstimate struct {
+	MedianSeconds float64 `json:"median_seconds"`
+	P90Seconds    float64 `json:"p90_seconds"`
+	SampleSize    int     `json:"sample_size"`
+}
+
+// CostEstimate represents the response for the estimate endpoint
+type CostEstimate struct {
+	ModelID          string            `json:"model_id"`
+	Credits          float64           `json:"credits"`
+	Breakdown        CostBreakdown     `json:"breakdown"`
+	ExpectedDuration *DurationEstimate `json:"expected_duration,omitempty"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "context"
+
+// Scope represents a permission granted to a principal
+type Scope string
+
+const (
+	// ScopeGenerate allows generating images
+	ScopeGenerate Scope = "generate"
+	// ScopeReadHistory allows reading generations and in-flight jobs
+	ScopeReadHistory Scope = "read-history"
+	// ScopeAdmin allows managing the server and implies every other scope
+	ScopeAdmin Scope = "admin"
+)
+
+// ValidScopes lists every scope that can be granted
+var ValidScopes = []Scope{ScopeGenerate, ScopeReadHistory, ScopeAdmin}
+
+// IsValid reports whether the scope is known
+func (s Scope) IsValid() bool {
+	for _, valid := range ValidScopes {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Principal kinds
+const (
+	PrincipalAPIKey = "api_key"
+	PrincipalUser   = "user"
+)
+
+// Principal represents the authenticated caller of a request
+type Principal struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// TenantID is the organization the principal acts for
+	TenantID string   `json:"tenant_id"`
+	Roles    []string `json:"roles,omitempty"`
+	Scopes   []Scope  `json:"scopes"`
+}
+
+// HasScope reports whether the principal was granted the scope, directly or through admin
+func (p *Principal) HasScope(scope Scope) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal attached to ctx, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
@@ -1,5 +1,7 @@
 package models
 
+import "strconv"
+
 // Text2ImgRequest represents the request structure for text-to-image generation
 type Text2ImgRequest struct {
 	Key               string  `json:"key"`
@@ -57,3 +59,32 @@ type ModelsLabAPIRequest struct {
 	Webhook           string  `json:"webhook,omitempty"`
 	TrackID           string  `json:"track_id,omitempty"`
 }
+
+// SetAPIKey sets the ModelsLab API key on the request
+func (r *ModelsLabAPIRequest) SetAPIKey(key string) {
+	r.Key = key
+}
+
+// ModelsLabKeyRequest represents a ModelsLab API request that carries only the API key
+type ModelsLabKeyRequest struct {
+	Key string `json:"key"`
+}
+
+// SetAPIKey sets the ModelsLab API key on the request
+func (r *ModelsLabKeyRequest) SetAPIKey(key string) {
+	r.Key = key
+}
+
+// UpscaleFactor returns how many times larger each side of the output is. ModelsLab
+// documents upscale "1" as 2x, and each higher value adds another multiple.
+func (r *Text2ImgRequest) UpscaleFactor() int {
+	if n, err := strconv.Atoi(r.Upscale); err == nil && n > 0 {
+		return n + 1
+	}
+	return 1
+}
+
+// BilledImages returns the number of images a request counts as: samples × upscale factor
+func (r *Text2ImgRequest) BilledImages() int {
+	return r.Samples * r.UpscaleFactor()
+}
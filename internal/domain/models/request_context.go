@@ -0,0 +1,43 @@
+package models
+
+import "context"
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the caller's IP address
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the caller's IP address attached to ctx, if any
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID attached to ctx, if any
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+type upstreamKeyKey struct{}
+
+// WithUpstreamKey returns a copy of ctx whose ModelsLab requests use the pooled key with the
+// given name, as needed for calls about a job that only the key that created it can see
+func WithUpstreamKey(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, upstreamKeyKey{}, name)
+}
+
+// UpstreamKeyFromContext returns the name of the upstream key pinned in ctx, if any
+func UpstreamKeyFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(upstreamKeyKey{}).(string)
+	return name
+}
@@ -1,22 +1,74 @@
 package models
 
+import apperrors "image/pkg/errors"
+
 // ErrorResponse represents an error response from the API
 type ErrorResponse struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 	Code    string `json:"code"`
+	// RequestID identifies the failed request in logs
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// NewErrorResponse converts an error into an ErrorResponse, hiding details of non-application errors
+func NewErrorResponse(err error) ErrorResponse {
+	appErr := apperrors.FromError(err)
+	return ErrorResponse{
+		Status:  "error",
+		Message: appErr.Message,
+		Code:    string(appErr.Code),
+	}
 }
 
 // Text2ImgResponse represents the response from the text-to-image endpoint
 type Text2ImgResponse struct {
-	Status         string   `json:"status"`
-	Message        string   `json:"message,omitempty"`
-	GenerationTime float64  `json:"generation_time,omitempty"`
-	Output         []string `json:"output,omitempty"`
-	Images         []string `json:"images,omitempty"`
-	TaskID         string   `json:"task_id,omitempty"`
-	Progress       float64  `json:"progress,omitempty"`
-	ID             int64    `json:"id,omitempty"`
+	Status         string          `json:"status"`
+	Message        string          `json:"message,omitempty"`
+	GenerationTime float64         `json:"generation_time,omitempty"`
+	Output         []string        `json:"output,omitempty"`
+	Images         []string        `json:"images,omitempty"`
+	TaskID         string          `json:"task_id,omitempty"`
+	Progress       float64         `json:"progress,omitempty"`
+	ETA            float64         `json:"eta,omitempty"`
+	ID             int64           `json:"id,omitempty"`
+	Meta           *GenerationMeta `json:"meta,omitempty"`
+	GenerationID   string          `json:"generation_id,omitempty"`
+}
+
+// GenerationMeta represents the parameters ModelsLab actually used for a generation
+type GenerationMeta struct {
+	Prompt         string  `json:"prompt"`
+	ModelID        string  `json:"model_id"`
+	NegativePrompt string  `json:"negative_prompt,omitempty"`
+	Scheduler      string  `json:"scheduler,omitempty"`
+	SafetyChecker  string  `json:"safetychecker,omitempty"`
+	W              int     `json:"W"`
+	H              int     `json:"H"`
+	GuidanceScale  float64 `json:"guidance_scale"`
+	Seed           int64   `json:"seed"`
+	Steps          int     `json:"steps"`
+	NSamples       int     `json:"n_samples"`
+}
+
+// NewGenerationMeta builds the meta block from the request that was sent upstream
+func NewGenerationMeta(req *Text2ImgRequest) *GenerationMeta {
+	meta := &GenerationMeta{
+		Prompt:         req.Prompt,
+		ModelID:        req.ModelID,
+		NegativePrompt: req.NegativePrompt,
+		Scheduler:      req.Scheduler,
+		SafetyChecker:  req.SafetyChecker,
+		W:              req.Width,
+		H:              req.Height,
+		GuidanceScale:  req.GuidanceScale,
+		Steps:          req.NumInferenceSteps,
+		NSamples:       req.Samples,
+	}
+	if req.Seed != nil {
+		meta.Seed = *req.Seed
+	}
+	return meta
 }
 
 // IsProcessing returns true if the response indicates the request is still processing
@@ -0,0 +1,31 @@
+package models
+
+// APIRoutes lists the names of the API routes, as registered with the server and used to
+// key per-route settings such as CORS rules, rate limits and role policy endpoints
+var APIRoutes = []string{
+	"models",
+	"text2img",
+	"batch",
+	"sweep",
+	"estimate",
+	"jobs",
+	"cancel",
+	"generation",
+	"reproduce",
+	"apikeys",
+	"revokekey",
+	"usage",
+	"usageexport",
+	"tenantsusageexport",
+	"audit",
+	"auditexport",
+	"upstreamkeys",
+	"reloadupstreamkeys",
+	"loglevel",
+	"metrics",
+}
+
+// IsAPIRoute reports whether name is the name of an API route
+func IsAPIRoute(name string) bool {
+	return containsString(APIRoutes, name)
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	apperrors "image/pkg/errors"
+)
+
+// sweepableParameters lists the Text2ImgRequest fields that may be used as sweep axes
+var sweepableParameters = map[string]bool{
+	"model_id":            true,
+	"prompt":              true,
+	"negative_prompt":     true,
+	"width":               true,
+	"height":              true,
+	"samples":             true,
+	"num_inference_steps": true,
+	"safety_checker":      true,
+	"enhance_prompt":      true,
+	"seed":                true,
+	"guidance_scale":      true,
+	"panorama":            true,
+	"self_attention":      true,
+	"upscale":             true,
+	"embeddings_model":    true,
+	"lora_model":          true,
+	"tomesd":              true,
+	"clip_skip":           true,
+	"use_karras_sigmas":   true,
+	"vae":                 true,
+	"lora_strength":       true,
+	"scheduler":           true,
+}
+
+// SweepRequest represents a base request plus the parameter axes to vary
+type SweepRequest struct {
+	Base Text2ImgRequest `json:"base"`
+	Axes []SweepAxis     `json:"axes"`
+}
+
+// SweepAxis represents one parameter and the values it takes across the grid
+type SweepAxis struct {
+	Parameter string            `json:"parameter"`
+	Values    []json.RawMessage `json:"values"`
+}
+
+// SweepCell represents a single combination of parameters in the grid and its outcome
+type SweepCell struct {
+	Index          int                        `json:"index"`
+	Parameters     map[string]json.RawMessage `json:"parameters"`
+	Status         string                     `json:"status"`
+	Output         []string                   `json:"output,omitempty"`
+	GenerationTime float64                    `json:"generation_time,omitempty"`
+	ID             int64                      `json:"id,omitempty"`
+	Error          *ErrorResponse             `json:"error,omitempty"`
+}
+
+// SweepResponse is the grid manifest mapping each cell's parameters to its outputs
+type SweepResponse struct {
+	Status    string      `json:"status"`
+	Axes      []SweepAxis `json:"axes"`
+	Total     int         `json:"total"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	Cells     []SweepCell `json:"cells"`
+}
+
+// CellCount returns the number of cells in the cartesian product of the axes,
+// saturating at math.MaxInt32 so oversized grids cannot overflow
+func (s *SweepRequest) CellCount() int {
+	count := 1
+	for _, axis := range s.Axes {
+		count *= len(axis.Values)
+		if count > math.MaxInt32 {
+			return math.MaxInt32
+		}
+	}
+	return count
+}
+
+// Validate checks that the axes name sweepable parameters and are not empty
+func (s *SweepRequest) Validate() error {
+	if len(s.Axes) == 0 {
+		return apperrors.NewInvalidRequestError("At least one sweep axis is required", nil)
+	}
+
+	seen := make(map[string]bool, len(s.Axes))
+	for _, axis := range s.Axes {
+		if !sweepableParameters[axis.Parameter] {
+			return apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Parameter %q cannot be swept", axis.Parameter),
+				nil,
+			)
+		}
+		if seen[axis.Parameter] {
+			return apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Parameter %q appears on more than one axis", axis.Parameter),
+				nil,
+			)
+		}
+		if len(axis.Values) == 0 {
+			return apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Axis %q has no values", axis.Parameter),
+				nil,
+			)
+		}
+		seen[axis.Parameter] = true
+	}
+
+	return nil
+}
+
+// Expand returns the parameters and request for every cell of the grid, in row-major order
+// with the last axis varying fastest
+func (s *SweepRequest) Expand() ([]map[string]json.RawMessage, []Text2ImgRequest, error) {
+	base, err := json.Marshal(s.Base)
+	if err != nil {
+		return nil, nil, apperrors.NewInvalidRequestError("Invalid base request", err)
+	}
+
+	count := s.CellCount()
+	params := make([]map[string]json.RawMessage, 0, count)
+	requests := make([]Text2ImgRequest, 0, count)
+
+	indices := make([]int, len(s.Axes))
+	for cell := 0; cell < count; cell++ {
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(base, &fields); err != nil {
+			return nil, nil, apperrors.NewInvalidRequestError("Invalid base request", err)
+		}
+
+		cellParams := make(map[string]json.RawMessage, len(s.Axes))
+		for i, axis := range s.Axes {
+			value := axis.Values[indices[i]]
+			fields[axis.Parameter] = value
+			cellParams[axis.Parameter] = value
+		}
+
+		merged, err := json.Marshal(fields)
+		if err != nil {
+			return nil, nil, apperrors.NewInvalidRequestError("Invalid sweep values", err)
+		}
+
+		var req Text2ImgRequest
+		if err := json.Unmarshal(merged, &req); err != nil {
+			return nil, nil, apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Invalid sweep values for cell %d", cell),
+				err,
+			)
+		}
+
+		params = append(params, cellParams)
+		requests = append(requests, req)
+
+		// Advance the odometer, last axis first
+		for i := len(indices) - 1; i >= 0; i-- {
+			indices[i]++
+			if indices[i] < len(s.Axes[i].Values) {
+				break
+			}
+			indices[i] = 0
+		}
+	}
+
+	return params, requests, nil
+}
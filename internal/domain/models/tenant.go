@@ -0,0 +1,79 @@
+package models
+
+import "context"
+
+// DefaultTenantID is the tenant of callers that do not belong to an organization,
+// including every request made while authentication is disabled
+const DefaultTenantID = "default"
+
+// RequestDefaults fills in generation parameters a request leaves unset
+type RequestDefaults struct {
+	NegativePrompt    string  `json:"negative_prompt,omitempty"`
+	Width             int     `json:"width,omitempty"`
+	Height            int     `json:"height,omitempty"`
+	Samples           int     `json:"samples,omitempty"`
+	NumInferenceSteps int     `json:"num_inference_steps,omitempty"`
+	GuidanceScale     float64 `json:"guidance_scale,omitempty"`
+	SafetyChecker     string  `json:"safety_checker,omitempty"`
+	EnhancePrompt     string  `json:"enhance_prompt,omitempty"`
+	Scheduler         string  `json:"scheduler,omitempty"`
+}
+
+// Apply sets every zero-valued request field that has a default
+func (d RequestDefaults) Apply(req *Text2ImgRequest) {
+	if req.NegativePrompt == "" {
+		req.NegativePrompt = d.NegativePrompt
+	}
+	if req.Width == 0 {
+		req.Width = d.Width
+	}
+	if req.Height == 0 {
+		req.Height = d.Height
+	}
+	if req.Samples == 0 {
+		req.Samples = d.Samples
+	}
+	if req.NumInferenceSteps == 0 {
+		req.NumInferenceSteps = d.NumInferenceSteps
+	}
+	if req.GuidanceScale == 0 {
+		req.GuidanceScale = d.GuidanceScale
+	}
+	if req.SafetyChecker == "" {
+		req.SafetyChecker = d.SafetyChecker
+	}
+	if req.EnhancePrompt == "" {
+		req.EnhancePrompt = d.EnhancePrompt
+	}
+	if req.Scheduler == "" {
+		req.Scheduler = d.Scheduler
+	}
+}
+
+// ModelOverrides restricts and customizes the registry's models for one tenant
+type ModelOverrides struct {
+	// AllowedModels lists the model IDs the tenant may use; empty allows every model
+	AllowedModels []string `json:"allowed_models,omitempty"`
+	// Defaults holds request defaults keyed by model ID
+	Defaults map[string]RequestDefaults `json:"defaults,omitempty"`
+}
+
+// Allows reports whether the tenant may use the model
+func (o ModelOverrides) Allows(modelID string) bool {
+	return len(o.AllowedModels) == 0 || containsString(o.AllowedModels, modelID)
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant ID
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID attached to ctx, or DefaultTenantID when there is none
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenantID
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"image/pkg/secret"
+)
+
+// Upstream key selection strategies
+const (
+	KeyStrategyRoundRobin = "round-robin"
+	KeyStrategyCredit     = "credit"
+)
+
+// UpstreamKey is a ModelsLab API key in the pool used for upstream requests
+type UpstreamKey struct {
+	Name string        `json:"name"`
+	Key  secret.Secret `json:"key"`
+	// Credit is the key's budget for the credit strategy; zero leaves it untracked
+	Credit float64 `json:"credit,omitempty"`
+}
+
+// UpstreamKeyStatus reports the health of a pooled key without its secret
+type UpstreamKeyStatus struct {
+	Name          string     `json:"name"`
+	Prefix        string     `json:"prefix"`
+	Healthy       bool       `json:"healthy"`
+	Reason        string     `json:"reason,omitempty"`
+	CooldownUntil *time.Time `json:"cooldown_until,omitempty"`
+	// RemainingCredit is only set for keys with a tracked budget
+	RemainingCredit *float64 `json:"remaining_credit,omitempty"`
+	Requests        int64    `json:"requests"`
+	Rejections      int64    `json:"rejections"`
+}
+
+// UpstreamKeysResponse represents the response for the upstream key pool endpoints
+type UpstreamKeysResponse struct {
+	Strategy string              `json:"strategy"`
+	Keys     []UpstreamKeyStatus `json:"keys"`
+}
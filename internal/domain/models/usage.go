@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// AnonymousPrincipalID identifies usage made while authentication is disabled
+const AnonymousPrincipalID = "anonymous"
+
+// UsageRecord represents the resources consumed by a single generation
+type UsageRecord struct {
+	ID             string    `json:"id"`
+	TenantID       string    `json:"tenant_id"`
+	PrincipalID    string    `json:"principal_id"`
+	PrincipalName  string    `json:"principal_name"`
+	ModelID        string    `json:"model_id"`
+	GenerationID   string    `json:"generation_id,omitempty"`
+	Images         int       `json:"images"`
+	Steps          int       `json:"steps"`
+	GenerationTime float64   `json:"generation_time"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// UsageFilter narrows usage queries; zero values match everything
+type UsageFilter struct {
+	PrincipalID string
+	From        time.Time
+	To          time.Time
+	// AllTenants includes the records of every tenant rather than only the caller's
+	AllTenants bool
+}
+
+// Matches reports whether the record satisfies the filter
+func (f UsageFilter) Matches(record *UsageRecord) bool {
+	if f.PrincipalID != "" && record.PrincipalID != f.PrincipalID {
+		return false
+	}
+	if !f.From.IsZero() && record.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !record.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Quota limits the images a principal may generate per day and per month; zero means unlimited
+type Quota struct {
+	DailyImages   int `json:"daily_images"`
+	MonthlyImages int `json:"monthly_images"`
+}
+
+// IsUnlimited reports whether the quota sets no limit
+func (q Quota) IsUnlimited() bool {
+	return q.DailyImages == 0 && q.MonthlyImages == 0
+}
+
+// QuotaStatus reports a principal's consumption against its quota
+type QuotaStatus struct {
+	Quota
+	UsedToday     int `json:"used_today"`
+	UsedThisMonth int `json:"used_this_month"`
+}
+
+// UsageSummary aggregates usage for one principal
+type UsageSummary struct {
+	PrincipalID    string  `json:"principal_id"`
+	PrincipalName  string  `json:"principal_name"`
+	Generations    int     `json:"generations"`
+	Images         int     `json:"images"`
+	Steps          int     `json:"steps"`
+	GenerationTime float64 `json:"generation_time"`
+}
+
+// UsageReport represents the response for the usage endpoint
+type UsageReport struct {
+	From       time.Time      `json:"from"`
+	To         time.Time      `json:"to"`
+	Principals []UsageSummary `json:"principals"`
+	Quota      *QuotaStatus   `json:"quota,omitempty"`
+}
+
+// PrincipalOrAnonymous returns the principal attached to ctx, or an anonymous
+// principal when authentication is disabled
+func PrincipalOrAnonymous(ctx context.Context) *Principal {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal
+	}
+	return &Principal{ID: AnonymousPrincipalID, Name: AnonymousPrincipalID}
+}
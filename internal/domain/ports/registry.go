@@ -14,4 +14,16 @@ type ModelRegistry interface {
 	List() []models.AIModel
 	// Validate checks if a model ID is valid
 	Validate(id string) error
+	// SetPricing configures the pricing rules of a registered model
+	SetPricing(id string, pricing models.Pricing) error
+	// Pricing returns the pricing rules of a model
+	Pricing(id string) (models.Pricing, error)
+	// SetTenantOverrides restricts and customizes the models available to a tenant
+	SetTenantOverrides(tenantID string, overrides models.ModelOverrides) error
+	// GetForTenant retrieves a model the tenant is allowed to use
+	GetForTenant(tenantID, id string) (models.AIModel, error)
+	// ListForTenant returns the models the tenant is allowed to use
+	ListForTenant(tenantID string) []models.AIModel
+	// ApplyDefaults fills unset request parameters with the tenant's defaults for the model
+	ApplyDefaults(tenantID string, req *models.Text2ImgRequest)
 }
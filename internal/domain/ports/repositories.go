@@ -0,0 +1,56 @@
+package ports
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+)
+
+// GenerationRepository defines the interface for storing completed generations
+type GenerationRepository interface {
+	// Save stores a generation
+	Save(ctx context.Context, generation *models.Generation) error
+	// Get retrieves a generation by its ID
+	Get(ctx context.Context, id string) (*models.Generation, error)
+}
+
+// APIKeyRepository defines the interface for storing API keys
+type APIKeyRepository interface {
+	// Save creates or updates a key
+	Save(ctx context.Context, key *models.APIKey) error
+	// Get retrieves a key by its ID
+	Get(ctx context.Context, id string) (*models.APIKey, error)
+	// GetByHash retrieves a key by the hash of its secret
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	// List returns all keys, including revoked ones
+	List(ctx context.Context) ([]*models.APIKey, error)
+}
+
+// UsageRepository defines the interface for storing usage records
+type UsageRepository interface {
+	// Record appends a usage record
+	Record(ctx context.Context, record *models.UsageRecord) error
+	// List returns the records matching the filter, oldest first
+	List(ctx context.Context, filter models.UsageFilter) ([]*models.UsageRecord, error)
+}
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	// Append chains the event to the log, setting its sequence and hashes
+	Append(ctx context.Context, event *models.AuditEvent) error
+	// List returns the events matching the filter, oldest first
+	List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error)
+	// Verify reports whether the hash chain of the whole log holds, as checked when it was
+	// loaded and as events are appended
+	Verify(ctx context.Context) error
+}
+
+// PendingJobRepository defines the interface for storing generations still processing upstream
+type PendingJobRepository interface {
+	// Save creates or updates a pending job
+	Save(ctx context.Context, job *models.PendingJob) error
+	// List returns all pending jobs, oldest first
+	List(ctx context.Context) ([]*models.PendingJob, error)
+	// Delete removes a pending job by its generation ID; unknown IDs are ignored
+	Delete(ctx context.Context, generationID string) error
+}
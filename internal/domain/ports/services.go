@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"image/internal/domain/models"
 )
@@ -13,6 +14,100 @@ type ModelsLabService interface {
 	GenerateImage(ctx context.Context, req *models.Text2ImgRequest) (*models.Text2ImgResponse, error)
 }
 
+// ResumedGenerationRecorder accounts for generations that completed outside a live
+// request, such as upstream jobs resumed after a restart, which decorators of the
+// ModelsLabService never see
+type ResumedGenerationRecorder interface {
+	// RecordResumed records a generation completed for the caller in ctx
+	RecordResumed(ctx context.Context, req *models.Text2ImgRequest, resp *models.Text2ImgResponse)
+}
+
+// JobService defines the interface for managing in-flight generations
+type JobService interface {
+	// ListJobs returns the generations that are currently in flight
+	ListJobs(ctx context.Context) []models.Job
+	// CancelJob stops an in-flight generation by its job ID or upstream ID
+	CancelJob(ctx context.Context, id string) (*models.Job, error)
+}
+
+// BatchService defines the interface for generating several images in one request
+type BatchService interface {
+	// Generate fans the batch out to the image generator and collects per-item results
+	Generate(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error)
+	// GenerateItems generates already expanded requests without applying the batch size limit
+	GenerateItems(ctx context.Context, items []models.Text2ImgRequest) *models.BatchResponse
+}
+
+// SweepService defines the interface for parameter sweep (grid) generation
+type SweepService interface {
+	// Generate expands the sweep axes, validates every cell and generates the grid
+	Generate(ctx context.Context, req *models.SweepRequest) (*models.SweepResponse, error)
+}
+
+// GenerationService defines the interface for looking up and replaying past generations
+type GenerationService interface {
+	// Get returns a recorded generation by its ID
+	Get(ctx context.Context, id string) (*models.Generation, error)
+	// Reproduce replays a recorded generation with its exact parameters and seed
+	Reproduce(ctx context.Context, id string) (*models.Text2ImgResponse, error)
+}
+
+// Authenticator defines the interface for resolving request credentials to a principal
+type Authenticator interface {
+	// Authenticate resolves a presented credential to the principal it belongs to
+	Authenticate(ctx context.Context, credential string) (*models.Principal, error)
+}
+
+// APIKeyService defines the interface for issuing and checking API keys
+type APIKeyService interface {
+	Authenticator
+	// Create mints a new key and returns its secret once
+	Create(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.CreateAPIKeyResponse, error)
+	// List returns all keys without their hashes
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Revoke permanently disables a key
+	Revoke(ctx context.Context, id string) error
+}
+
+// AccessPolicy defines the interface for role-based access to models, capabilities and endpoints
+type AccessPolicy interface {
+	// AllowsModel reports whether the caller may use the model
+	AllowsModel(ctx context.Context, modelID string) bool
+	// AllowsEndpoint reports whether the caller may call the named route
+	AllowsEndpoint(ctx context.Context, route string) bool
+	// Authorize returns a forbidden error when the caller may not make the request
+	Authorize(ctx context.Context, req *models.Text2ImgRequest) error
+}
+
+// UsageService defines the interface for usage reporting and quota status
+type UsageService interface {
+	// Report aggregates usage per principal over a period
+	Report(ctx context.Context, filter models.UsageFilter) (*models.UsageReport, error)
+	// Records returns the raw usage records matching the filter
+	Records(ctx context.Context, filter models.UsageFilter) ([]*models.UsageRecord, error)
+	// QuotaStatus returns a principal's consumption against its quota
+	QuotaStatus(ctx context.Context, principalID string) (*models.QuotaStatus, error)
+}
+
+// AuditLog defines the interface for recording audited actions
+type AuditLog interface {
+	// Record appends an event for the caller in ctx; err is the action's outcome
+	Record(ctx context.Context, action, target string, err error)
+}
+
+// AuditService defines the interface for recording and querying the audit log
+type AuditService interface {
+	AuditLog
+	// Query returns the events matching the filter and whether the log verified
+	Query(ctx context.Context, filter models.AuditFilter) (*models.AuditLogResponse, error)
+}
+
+// EstimateService defines the interface for estimating the cost of a generation
+type EstimateService interface {
+	// Estimate returns the expected credits and duration of a request
+	Estimate(ctx context.Context, req *models.Text2ImgRequest) (*models.CostEstimate, error)
+}
+
 // ImageGenerator defines the interface for image generation
 type ImageGenerator interface {
 	// Generate creates an image based on the provided parameters
@@ -35,6 +130,86 @@ type HTTPClient interface {
 	Get(ctx context.Context, path string, response interface{}) error
 }
 
+// UpstreamKeyPool defines the interface for the pool of ModelsLab API keys
+type UpstreamKeyPool interface {
+	// Acquire picks the key for the next upstream request
+	Acquire() (string, error)
+	// Named returns the key with the given name, whatever its health
+	Named(name string) (string, error)
+	// Name returns the name of a key, or an empty string when it is not in the pool
+	Name(key string) string
+	// Report records the upstream status of a request made with the key
+	Report(key string, status int, retryAfter time.Duration)
+	// Charge deducts credits spent with the key from its budget
+	Charge(key string, credits float64)
+	// Reload replaces the keys with a fresh load, resetting their health
+	Reload() error
+	// Status reports the health of every key without its secret
+	Status() []models.UpstreamKeyStatus
+	// Strategy returns how the pool picks keys
+	Strategy() string
+}
+
+// UpstreamKeyService defines the interface for inspecting and reloading the upstream key pool
+type UpstreamKeyService interface {
+	// Status reports the pool's strategy and the health of every key
+	Status(ctx context.Context) *models.UpstreamKeysResponse
+	// Reload reloads the keys without a restart
+	Reload(ctx context.Context) (*models.UpstreamKeysResponse, error)
+}
+
+// LogLevelController defines the interface for changing the log level at runtime
+type LogLevelController interface {
+	// Level returns the name of the current minimum level
+	Level() string
+	// SetLevel changes the minimum level by name
+	SetLevel(level string) error
+}
+
+// LogLevelService defines the interface for inspecting and changing the log level
+type LogLevelService interface {
+	// Level returns the current log level
+	Level(ctx context.Context) *models.LogLevel
+	// SetLevel changes the log level of the running server
+	SetLevel(ctx context.Context, req *models.LogLevel) (*models.LogLevel, error)
+}
+
+// HealthProbe defines the interface for checking a dependency the server needs to serve requests
+type HealthProbe interface {
+	// Name identifies the dependency in health reports
+	Name() string
+	// Check returns an error when the dependency is unavailable
+	Check(ctx context.Context) error
+}
+
+// HealthService defines the interface for liveness and readiness reporting
+type HealthService interface {
+	// Live reports that the process is up, without checking dependencies
+	Live(ctx context.Context) *models.HealthReport
+	// Ready runs every dependency probe and reports whether the server can serve requests
+	Ready(ctx context.Context) *models.HealthReport
+}
+
+// Metrics defines the interface for recording operational metrics
+type Metrics interface {
+	// ObserveRequest records a served HTTP request
+	ObserveRequest(route, method string, status int, duration time.Duration)
+	// ObserveUpstream records an upstream call attempt; status is zero when no response arrived
+	ObserveUpstream(endpoint string, status int, duration time.Duration, retry bool)
+	// ObservePolling records a finished polling loop for a processing generation
+	ObservePolling(modelID string, attempts int, duration time.Duration, outcome string)
+	// GenerationStarted counts a generation as in flight
+	GenerationStarted(modelID string)
+	// GenerationFinished stops counting a generation as in flight
+	GenerationFinished(modelID string)
+}
+
+// MetricsExporter defines the interface for exposing recorded metrics
+type MetricsExporter interface {
+	// Handler serves every metric in the Prometheus exposition format
+	Handler() http.Handler
+}
+
 // Logger defines the interface for logging operations
 type Logger interface {
 	// Info logs information messages
@@ -43,4 +218,10 @@ type Logger interface {
 	Error(msg string, err error, fields ...interface{})
 	// Debug logs debug messages
 	Debug(msg string, fields ...interface{})
+	// InfoContext logs information messages with the request metadata carried by ctx
+	InfoContext(ctx context.Context, msg string, fields ...interface{})
+	// ErrorContext logs error messages with the request metadata carried by ctx
+	ErrorContext(ctx context.Context, msg string, err error, fields ...interface{})
+	// DebugContext logs debug messages with the request metadata carried by ctx
+	DebugContext(ctx context.Context, msg string, fields ...interface{})
 }
@@ -0,0 +1,90 @@
+package apikeys
+
+import (
+	"net/http"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+	apperrors "image/pkg/errors"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler lists and mints API keys
+type Handler struct {
+	service ports.APIKeyService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new API keys handler
+func NewHandler(service ports.APIKeyService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.list(w, r)
+	case http.MethodPost:
+		h.create(w, r)
+	default:
+		respond.Error(w, r, h.logger, apperrors.NewInvalidRequestError("Method not allowed", nil))
+	}
+}
+
+// list writes all API keys
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.List(r.Context())
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, models.APIKeysResponse{Keys: keys})
+}
+
+// create mints a new API key
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIKeyRequest
+	if err := respond.Decode(r, &req); err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	resp, err := h.service.Create(r.Context(), &req)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusCreated, resp)
+}
+
+// RevokeHandler revokes an API key
+type RevokeHandler struct {
+	service ports.APIKeyService
+	logger  ports.Logger
+}
+
+// NewRevokeHandler creates a new API key revoke handler
+func NewRevokeHandler(service ports.APIKeyService, logger ports.Logger) *RevokeHandler {
+	return &RevokeHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *RevokeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.Revoke(r.Context(), mux.Vars(r)["id"]); err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+	apperrors "image/pkg/errors"
+)
+
+// Handler queries the audit log
+type Handler struct {
+	service ports.AuditService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new audit log handler
+func NewHandler(service ports.AuditService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	response, err := h.service.Query(r.Context(), filter)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, response)
+}
+
+// ExportHandler exports audit events as JSON lines, one event per line
+type ExportHandler struct {
+	service ports.AuditService
+	logger  ports.Logger
+}
+
+// NewExportHandler creates a new audit log JSONL export handler
+func NewExportHandler(service ports.AuditService, logger ports.Logger) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	response, err := h.service.Query(r.Context(), filter)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.jsonl"`)
+
+	encoder := json.NewEncoder(w)
+	for i := range response.Events {
+		if err := encoder.Encode(&response.Events[i]); err != nil {
+			h.logger.ErrorContext(r.Context(), "Failed to write audit export", err)
+			return
+		}
+	}
+}
+
+// parseFilter reads the actor, action, target and period from the query string
+func parseFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+
+	filter := models.AuditFilter{
+		ActorID: query.Get("actor_id"),
+		Action:  query.Get("action"),
+		Target:  query.Get("target"),
+	}
+
+	if value := query.Get("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, apperrors.NewInvalidRequestError("Invalid from parameter", err)
+		}
+		filter.From = from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, apperrors.NewInvalidRequestError("Invalid to parameter", err)
+		}
+		filter.To = to
+	}
+
+	return filter, nil
+}
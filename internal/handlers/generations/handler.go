@@ -0,0 +1,60 @@
+package generations
+
+import (
+	"net/http"
+
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler returns a recorded generation
+type Handler struct {
+	service ports.GenerationService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new generation lookup handler
+func NewHandler(service ports.GenerationService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	generation, err := h.service.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, generation)
+}
+
+// ReproduceHandler replays a recorded generation with its exact parameters
+type ReproduceHandler struct {
+	service ports.GenerationService
+	logger  ports.Logger
+}
+
+// NewReproduceHandler creates a new generation reproduce handler
+func NewReproduceHandler(service ports.GenerationService, logger ports.Logger) *ReproduceHandler {
+	return &ReproduceHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *ReproduceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.service.Reproduce(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, resp)
+}
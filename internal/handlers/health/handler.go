@@ -1,49 +1,54 @@
 package health
 
 import (
-	"encoding/json"
 	"net/http"
-	"time"
 
 	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
 )
 
-// Handler implements the HealthHandler interface
+// Handler reports liveness: the process is up and serving HTTP
 type Handler struct {
-	logger ports.Logger
+	service ports.HealthService
+	logger  ports.Logger
 }
 
-// HealthResponse represents the health check response
-type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
-}
-
-// NewHandler creates a new health check handler
-func NewHandler(logger ports.Logger) *Handler {
+// NewHandler creates a new liveness handler
+func NewHandler(service ports.HealthService, logger ports.Logger) *Handler {
 	return &Handler{
-		logger: logger,
+		service: service,
+		logger:  logger,
 	}
 }
 
-// Handle processes health check requests
-func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
-	response := HealthResponse{
-		Status:    "ok",
-		Timestamp: time.Now().UTC(),
-		Version:   "1.0.0",
-	}
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, h.logger, http.StatusOK, h.service.Live(r.Context()))
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode health check response", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+// ReadyHandler reports readiness: every dependency probe passed. Degraded readiness is
+// reported with 503 so load balancers stop routing traffic to the server.
+type ReadyHandler struct {
+	service ports.HealthService
+	logger  ports.Logger
+}
+
+// NewReadyHandler creates a new readiness handler
+func NewReadyHandler(service ports.HealthService, logger ports.Logger) *ReadyHandler {
+	return &ReadyHandler{
+		service: service,
+		logger:  logger,
 	}
 }
 
 // ServeHTTP implements the http.Handler interface
-func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	h.Handle(w, r)
+func (h *ReadyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := h.service.Ready(r.Context())
+
+	status := http.StatusOK
+	if !report.IsHealthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	respond.JSON(w, h.logger, status, report)
 }
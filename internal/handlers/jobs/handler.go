@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"net/http"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler lists in-flight generations
+type Handler struct {
+	service ports.JobService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new jobs handler
+func NewHandler(service ports.JobService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, h.logger, http.StatusOK, models.JobsResponse{
+		Jobs: h.service.ListJobs(r.Context()),
+	})
+}
+
+// CancelHandler cancels an in-flight generation
+type CancelHandler struct {
+	service ports.JobService
+	logger  ports.Logger
+}
+
+// NewCancelHandler creates a new job cancel handler
+func NewCancelHandler(service ports.JobService, logger ports.Logger) *CancelHandler {
+	return &CancelHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *CancelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	job, err := h.service.CancelJob(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, job)
+}
@@ -0,0 +1,53 @@
+package loglevel
+
+import (
+	"net/http"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+	apperrors "image/pkg/errors"
+)
+
+// Handler reports and changes the server's log level
+type Handler struct {
+	service ports.LogLevelService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new log level handler
+func NewHandler(service ports.LogLevelService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		respond.JSON(w, h.logger, http.StatusOK, h.service.Level(r.Context()))
+	case http.MethodPut:
+		h.set(w, r)
+	default:
+		respond.Error(w, r, h.logger, apperrors.NewInvalidRequestError("Method not allowed", nil))
+	}
+}
+
+// set changes the log level
+func (h *Handler) set(w http.ResponseWriter, r *http.Request) {
+	var req models.LogLevel
+	if err := respond.Decode(r, &req); err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	resp, err := h.service.SetLevel(r.Context(), &req)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, resp)
+}
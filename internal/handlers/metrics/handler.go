@@ -0,0 +1,26 @@
+package metrics
+
+import (
+	"net/http"
+
+	"image/internal/domain/ports"
+)
+
+// Handler exposes metrics for Prometheus to scrape
+type Handler struct {
+	handler http.Handler
+	logger  ports.Logger
+}
+
+// NewHandler creates a new metrics handler
+func NewHandler(exporter ports.MetricsExporter, logger ports.Logger) *Handler {
+	return &Handler{
+		handler: exporter.Handler(),
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler.ServeHTTP(w, r)
+}
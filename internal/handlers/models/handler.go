@@ -11,33 +11,37 @@ import (
 // Handler handles model-related requests
 type Handler struct {
 	registry ports.ModelRegistry
+	access   ports.AccessPolicy
 	logger   ports.Logger
 }
 
-// NewHandler creates a new models handler
-func NewHandler(registry ports.ModelRegistry, logger ports.Logger) *Handler {
+// NewHandler creates a new models handler listing the models the caller may use
+func NewHandler(registry ports.ModelRegistry, access ports.AccessPolicy, logger ports.Logger) *Handler {
 	return &Handler{
 		registry: registry,
+		access:   access,
 		logger:   logger,
 	}
 }
 
 // ServeHTTP implements the http.Handler interface
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	modelsList := h.registry.List()
+	modelsList := h.registry.ListForTenant(models.TenantFromContext(r.Context()))
 
 	// Convert models to response format
 	response := models.ModelsResponse{
-		Models: make([]models.ModelResponse, len(modelsList)),
+		Models: make([]models.ModelResponse, 0, len(modelsList)),
 	}
 
-	for i, model := range modelsList {
-		response.Models[i] = models.ToResponse(model)
+	for _, model := range modelsList {
+		if h.access.AllowsModel(r.Context(), model.ID()) {
+			response.Models = append(response.Models, models.ToResponse(model))
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode models response", err)
+		h.logger.ErrorContext(r.Context(), "Failed to encode models response", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
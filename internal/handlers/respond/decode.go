@@ -0,0 +1,66 @@
+package respond
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	apperrors "image/pkg/errors"
+)
+
+// Decode reads a JSON request body into v. Unknown fields and trailing data are rejected,
+// so a misspelled field is reported instead of silently falling back to its default.
+func Decode(r *http.Request, v interface{}) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return apperrors.NewInvalidRequestError("Request body must hold a single JSON object", err)
+	}
+
+	return nil
+}
+
+// decodeError describes why a request body could not be decoded
+func decodeError(err error) *apperrors.AppError {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apperrors.NewPayloadTooLargeError(
+			fmt.Sprintf("Request body exceeds the limit of %d bytes", maxBytesErr.Limit), err)
+	case errors.Is(err, io.EOF):
+		return apperrors.NewInvalidRequestError("Request body is empty", err)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperrors.NewInvalidRequestError("Request body is not valid JSON: unexpected end of body", err)
+	case errors.As(err, &syntaxErr):
+		return apperrors.NewInvalidRequestError(
+			fmt.Sprintf("Request body is not valid JSON at offset %d", syntaxErr.Offset), err)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return apperrors.NewInvalidRequestError("Request body must be a JSON object", err)
+		}
+		return apperrors.NewInvalidRequestError(
+			fmt.Sprintf("Field %s must be of type %s but got %s", typeErr.Field, typeErr.Type, typeErr.Value), err)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return apperrors.NewInvalidRequestError(fmt.Sprintf("Unknown field %s in request body", field), err)
+	default:
+		return apperrors.NewInvalidRequestError("Invalid request body", err)
+	}
+}
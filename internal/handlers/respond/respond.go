@@ -0,0 +1,39 @@
+package respond
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// JSON writes data as a JSON response with the given status code
+func JSON(w http.ResponseWriter, logger ports.Logger, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Error("Failed to encode response", err)
+	}
+}
+
+// Error writes an error response, using the status and code of application errors.
+// The body carries the request ID so callers can quote it when reporting problems.
+func Error(w http.ResponseWriter, r *http.Request, logger ports.Logger, err error) {
+	appErr := apperrors.FromError(err)
+	if appErr.Code == apperrors.ErrInternalServer {
+		logger.ErrorContext(r.Context(), "Request failed", err)
+	}
+
+	if appErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+
+	resp := models.NewErrorResponse(appErr)
+	resp.RequestID = models.RequestIDFromContext(r.Context())
+	JSON(w, logger, appErr.Status, resp)
+}
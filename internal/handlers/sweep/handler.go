@@ -0,0 +1,40 @@
+package sweep
+
+import (
+	"net/http"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+)
+
+// Handler handles parameter sweep requests
+type Handler struct {
+	service ports.SweepService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new sweep handler instance
+func NewHandler(service ports.SweepService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req models.SweepRequest
+	if err := respond.Decode(r, &req); err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	resp, err := h.service.Generate(r.Context(), &req)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, resp)
+}
@@ -1,14 +1,22 @@
 package text2img
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"image/internal/domain/models"
 	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+	"image/internal/infrastructure/tracing"
 	apperrors "image/pkg/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the spans of text-to-image requests
+var tracer = otel.Tracer("image/internal/handlers/text2img")
+
 // Handler implements the Text2ImgHandler interface
 type Handler struct {
 	service ports.ModelsLabService
@@ -25,9 +33,13 @@ func NewHandler(service ports.ModelsLabService, logger ports.Logger) *Handler {
 
 // Handle processes text-to-image generation requests
 func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "text2img.Handler.Handle")
+	defer span.End()
+	r = r.WithContext(ctx)
+
 	// Only allow POST method
 	if r.Method != http.MethodPost {
-		h.writeError(w, apperrors.NewInvalidRequestError(
+		h.writeError(w, r, apperrors.NewInvalidRequestError(
 			"Method not allowed",
 			nil,
 		))
@@ -36,18 +48,22 @@ func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	// Parse request body
 	var req models.Text2ImgRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.writeError(w, apperrors.NewInvalidRequestError(
-			"Invalid request body",
-			err,
-		))
+	if err := respond.Decode(r, &req); err != nil {
+		h.writeError(w, r, err)
 		return
 	}
 
+	span.SetAttributes(
+		attribute.String("model_id", req.ModelID),
+		attribute.Int("width", req.Width),
+		attribute.Int("height", req.Height),
+		attribute.Int("samples", req.Samples),
+	)
+
 	// Generate image
 	resp, err := h.service.GenerateImage(r.Context(), &req)
 	if err != nil {
-		h.writeError(w, err)
+		h.writeError(w, r, err)
 		return
 	}
 
@@ -62,43 +78,11 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // writeJSON writes a JSON response
 func (h *Handler) writeJSON(w http.ResponseWriter, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		h.logger.Error("Failed to encode response", err)
-		h.writeError(w, apperrors.NewInternalServerError(
-			"Failed to encode response",
-			err,
-		))
-	}
+	respond.JSON(w, h.logger, http.StatusOK, data)
 }
 
 // writeError writes an error response
-func (h *Handler) writeError(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
-
-	var response models.ErrorResponse
-	var status int
-
-	if appErr, ok := err.(*apperrors.AppError); ok {
-		response = models.ErrorResponse{
-			Status:  "error",
-			Message: appErr.Message,
-			Code:    string(appErr.Code),
-		}
-		status = appErr.Status
-	} else {
-		response = models.ErrorResponse{
-			Status:  "error",
-			Message: "Internal server error",
-			Code:    string(apperrors.ErrInternalServer),
-		}
-		status = http.StatusInternalServerError
-	}
-
-	w.WriteHeader(status)
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		h.logger.Error("Failed to encode error response", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-	}
+func (h *Handler) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	tracing.RecordError(trace.SpanFromContext(r.Context()), err)
+	respond.Error(w, r, h.logger, err)
 }
@@ -0,0 +1,52 @@
+package upstreamkeys
+
+import (
+	"net/http"
+
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+)
+
+// Handler reports the health of the upstream key pool
+type Handler struct {
+	service ports.UpstreamKeyService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new upstream key status handler
+func NewHandler(service ports.UpstreamKeyService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, h.logger, http.StatusOK, h.service.Status(r.Context()))
+}
+
+// ReloadHandler reloads the upstream key pool
+type ReloadHandler struct {
+	service ports.UpstreamKeyService
+	logger  ports.Logger
+}
+
+// NewReloadHandler creates a new upstream key reload handler
+func NewReloadHandler(service ports.UpstreamKeyService, logger ports.Logger) *ReloadHandler {
+	return &ReloadHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *ReloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Reload(r.Context())
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, status)
+}
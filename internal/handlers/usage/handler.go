@@ -0,0 +1,193 @@
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/handlers/respond"
+	apperrors "image/pkg/errors"
+)
+
+// Handler reports usage per principal
+type Handler struct {
+	service ports.UsageService
+	logger  ports.Logger
+}
+
+// NewHandler creates a new usage report handler
+func NewHandler(service ports.UsageService, logger ports.Logger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	report, err := h.service.Report(r.Context(), filter)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	if filter.PrincipalID != "" {
+		if report.Quota, err = h.service.QuotaStatus(r.Context(), filter.PrincipalID); err != nil {
+			respond.Error(w, r, h.logger, err)
+			return
+		}
+	}
+
+	respond.JSON(w, h.logger, http.StatusOK, report)
+}
+
+// ExportHandler exports raw usage records as CSV
+type ExportHandler struct {
+	service ports.UsageService
+	logger  ports.Logger
+	// allTenants exports the records of every tenant, for chargeback across teams
+	allTenants bool
+}
+
+// NewExportHandler creates a new usage CSV export handler for the caller's tenant
+func NewExportHandler(service ports.UsageService, logger ports.Logger) *ExportHandler {
+	return &ExportHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// NewTenantsExportHandler creates a usage CSV export handler covering every tenant; it must
+// only be reachable by the operator
+func NewTenantsExportHandler(service ports.UsageService, logger ports.Logger) *ExportHandler {
+	return &ExportHandler{
+		service:    service,
+		logger:     logger,
+		allTenants: true,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFilter(r)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	filter.AllTenants = h.allTenants
+
+	records, err := h.service.Records(r.Context(), filter)
+	if err != nil {
+		respond.Error(w, r, h.logger, err)
+		return
+	}
+
+	name := "usage"
+	if h.allTenants {
+		name = "usage-all-tenants"
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(
+		`attachment; filename="%s-%s-%s.csv"`,
+		name,
+		filter.From.Format("20060102"),
+		filter.To.Format("20060102"),
+	))
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"created_at", "tenant_id", "principal_id", "principal_name", "model_id",
+		"generation_id", "images", "steps", "generation_time",
+	})
+	for _, record := range records {
+		writer.Write([]string{
+			record.CreatedAt.Format(time.RFC3339),
+			csvSafe(record.TenantID),
+			csvSafe(record.PrincipalID),
+			csvSafe(record.PrincipalName),
+			csvSafe(record.ModelID),
+			csvSafe(record.GenerationID),
+			strconv.Itoa(record.Images),
+			strconv.Itoa(record.Steps),
+			strconv.FormatFloat(record.GenerationTime, 'f', 2, 64),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to write usage export", err)
+	}
+}
+
+// csvSafe quotes a field that a spreadsheet would otherwise evaluate as a formula, since
+// principal names are chosen by whoever creates the key
+func csvSafe(field string) string {
+	if field != "" && strings.ContainsRune("=+-@\t\r", rune(field[0])) {
+		return "'" + field
+	}
+	return field
+}
+
+// parseFilter reads the period and principal from the query string. The period defaults to
+// the current month; callers without the admin scope only ever see their own usage.
+func parseFilter(r *http.Request) (models.UsageFilter, error) {
+	query := r.URL.Query()
+	now := time.Now().UTC()
+
+	filter := models.UsageFilter{
+		PrincipalID: query.Get("principal_id"),
+		From:        time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC),
+	}
+	filter.To = filter.From.AddDate(0, 1, 0)
+
+	if value := query.Get("from"); value != "" {
+		from, _, err := parseTime(value)
+		if err != nil {
+			return filter, apperrors.NewInvalidRequestError("Invalid from parameter", err)
+		}
+		filter.From = from
+	}
+
+	if value := query.Get("to"); value != "" {
+		to, dateOnly, err := parseTime(value)
+		if err != nil {
+			return filter, apperrors.NewInvalidRequestError("Invalid to parameter", err)
+		}
+		// A bare date includes the whole day
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+
+	if !filter.To.After(filter.From) {
+		return filter, apperrors.NewInvalidRequestError("to must be after from", nil)
+	}
+
+	if principal, ok := models.PrincipalFromContext(r.Context()); ok && !principal.HasScope(models.ScopeAdmin) {
+		filter.PrincipalID = principal.ID
+	}
+
+	return filter, nil
+}
+
+// parseTime accepts RFC 3339 timestamps or YYYY-MM-DD dates and reports which it got
+func parseTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t.UTC(), false, err
+}
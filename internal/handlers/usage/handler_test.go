@@ -0,0 +1,96 @@
+package usage
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/internal/infrastructure/storage"
+	"image/pkg/logger"
+)
+
+// repositoryService serves usage records straight from a repository
+type repositoryService struct {
+	ports.UsageService
+	repository ports.UsageRepository
+}
+
+func (s repositoryService) Records(ctx context.Context, filter models.UsageFilter) ([]*models.UsageRecord, error) {
+	return s.repository.List(ctx, filter)
+}
+
+func TestExportHandlers(t *testing.T) {
+	repository, err := storage.NewUsageRepository(filepath.Join(t.TempDir(), "usage.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	for _, record := range []struct{ tenant, principal string }{
+		{models.DefaultTenantID, "ops"},
+		{"acme", "alice"},
+		{"globex", "=HYPERLINK(\"x\")"},
+	} {
+		ctx := models.WithTenant(context.Background(), record.tenant)
+		if err := repository.Record(ctx, &models.UsageRecord{PrincipalID: record.principal, ModelID: "flux", Images: 1, CreatedAt: now}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	service := repositoryService{repository: repository}
+	log := logger.New(logger.WithOutput(io.Discard))
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		tenant  string
+		want    [][2]string
+	}{
+		{
+			name:    "tenant export",
+			handler: NewExportHandler(service, log),
+			tenant:  "acme",
+			want:    [][2]string{{"acme", "alice"}},
+		},
+		{
+			name:    "all tenants export",
+			handler: NewTenantsExportHandler(service, log),
+			tenant:  models.DefaultTenantID,
+			want: [][2]string{
+				{models.DefaultTenantID, "ops"},
+				{"acme", "alice"},
+				{"globex", "'=HYPERLINK(\"x\")"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v6/usage/export.csv", nil)
+			req = req.WithContext(models.WithTenant(req.Context(), tt.tenant))
+			rec := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(rec, req)
+
+			rows, err := csv.NewReader(rec.Body).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != len(tt.want)+1 || rows[0][1] != "tenant_id" || rows[0][2] != "principal_id" {
+				t.Fatalf("unexpected export %v", rows)
+			}
+			for i, want := range tt.want {
+				if got := [2]string{rows[i+1][1], rows[i+1][2]}; got != want {
+					t.Errorf("row %d: got tenant and principal %v, want %v", i+1, got, want)
+				}
+			}
+		})
+	}
+}
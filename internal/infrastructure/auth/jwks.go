@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"image/internal/domain/ports"
+)
+
+// minRefreshInterval limits how often the key set is reloaded outside the regular refresh,
+// so forged tokens or an unavailable provider cannot be used to hammer it
+const minRefreshInterval = time.Minute
+
+// jsonWebKey represents a single public key in a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet loads the public keys of an identity provider from a JWKS file or URL and
+// caches them, refreshing periodically and when a token references an unknown key
+type KeySet struct {
+	source          string
+	refreshInterval time.Duration
+	client          *http.Client
+	logger          ports.Logger
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refreshing is closed when the running background reload finishes
+	refreshing chan struct{}
+}
+
+// NewKeySet creates a key set for the given JWKS path or http(s) URL
+func NewKeySet(source string, refreshInterval time.Duration, logger ports.Logger) *KeySet {
+	return &KeySet{
+		source:          source,
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		logger:          logger,
+		keys:            make(map[string]crypto.PublicKey),
+	}
+}
+
+// Load fetches the key set, replacing the cached keys
+func (k *KeySet) Load(ctx context.Context) error {
+	k.mu.Lock()
+	k.attemptedAt = time.Now()
+	k.mu.Unlock()
+
+	data, err := k.read(ctx)
+	if err != nil {
+		return err
+	}
+
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to decode JWKS from %s: %w", k.source, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			k.logger.InfoContext(ctx, "Skipping unusable JWKS key", "kid", jwk.Kid, "reason", err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS from %s contains no usable signing keys", k.source)
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	k.mu.Unlock()
+
+	k.logger.InfoContext(ctx, "Loaded JWKS", "source", k.source, "keys", len(keys))
+
+	return nil
+}
+
+// Key returns the public key with the given ID. A stale set is refreshed in the background
+// while the cached keys keep being served; a key missing from the set (which happens after
+// the provider rotates keys) waits for a refresh. Refreshes are shared between callers and
+// attempted at most once per minRefreshInterval, so an unavailable provider or forged
+// tokens cannot make every request block on it.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := k.lookup(kid); ok {
+		k.mu.RLock()
+		stale := time.Since(k.fetchedAt) > k.refreshInterval
+		k.mu.RUnlock()
+
+		if stale {
+			k.startRefresh()
+		}
+		return key, nil
+	}
+
+	if done := k.startRefresh(); done != nil {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// startRefresh reloads the key set in the background unless a reload is already running or
+// was attempted less than minRefreshInterval ago. It returns a channel closed when the
+// running reload finishes, or nil when none is running.
+func (k *KeySet) startRefresh() <-chan struct{} {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.refreshing != nil {
+		return k.refreshing
+	}
+	if time.Since(k.attemptedAt) < minRefreshInterval {
+		return nil
+	}
+
+	done := make(chan struct{})
+	k.refreshing = done
+	k.attemptedAt = time.Now()
+
+	go func() {
+		// The reload outlives the request that started it, which others may be waiting on
+		k.refresh(context.Background())
+
+		k.mu.Lock()
+		k.refreshing = nil
+		k.mu.Unlock()
+		close(done)
+	}()
+
+	return done
+}
+
+// refresh reloads the key set, keeping the cached keys when the provider is unavailable
+func (k *KeySet) refresh(ctx context.Context) {
+	if err := k.Load(ctx); err != nil {
+		k.logger.ErrorContext(ctx, "Failed to refresh JWKS", err, "source", k.source)
+	}
+}
+
+// lookup returns a cached key; an empty kid matches when the set holds a single key
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if key, ok := k.keys[kid]; ok {
+		return key, true
+	}
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+// read returns the raw JWKS document from a file or URL
+func (k *KeySet) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(k.source, "http://") && !strings.HasPrefix(k.source, "https://") {
+		data, err := os.ReadFile(k.source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWKS request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS response: %w", err)
+	}
+
+	return data, nil
+}
+
+// publicKey converts the JWK into an RSA or ECDSA public key
+func (j *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBigInt(j.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(j.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent: %w", err)
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("exponent out of range")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := decodeBigInt(j.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(j.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", j.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+	}
+}
+
+// decodeBigInt decodes a base64url-encoded big-endian integer
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// signingAlgorithms maps the accepted JWS algorithms to their hash functions.
+// Symmetric and "none" algorithms are deliberately absent.
+var signingAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// JWTConfig holds the rules a token must satisfy and how its claims map to a principal
+type JWTConfig struct {
+	// Issuer must match the iss claim when set
+	Issuer string
+	// Audience must appear in the aud claim when set
+	Audience string
+	// Leeway tolerates clock skew when checking exp and nbf
+	Leeway time.Duration
+	// RolesClaim is the dotted path of the claim holding the user's roles, e.g. "realm_access.roles"
+	RolesClaim string
+	// TenantClaim is the dotted path of the claim holding the user's organization, e.g. "org_id".
+	// Tokens must carry it when set; users belong to the default tenant otherwise.
+	TenantClaim string
+	// RoleScopes grants scopes to users holding a role
+	RoleScopes map[string][]models.Scope
+	// DefaultScopes are granted to every authenticated user
+	DefaultScopes []models.Scope
+}
+
+// JWTAuthenticator implements the Authenticator interface for JWTs issued by an OIDC provider
+type JWTAuthenticator struct {
+	keys   *KeySet
+	config JWTConfig
+	logger ports.Logger
+}
+
+// NewJWTAuthenticator creates a new JWT authenticator backed by the key set
+func NewJWTAuthenticator(keys *KeySet, config JWTConfig, logger ports.Logger) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		keys:   keys,
+		config: config,
+		logger: logger,
+	}
+}
+
+// jwtHeader represents the JOSE header of a token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Authenticate verifies the token and maps its claims to a principal
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*models.Principal, error) {
+	claims, err := a.verify(ctx, token)
+	if err != nil {
+		a.logger.DebugContext(ctx, "Rejected JWT", "reason", err.Error())
+		return nil, apperrors.NewUnauthorizedError("Invalid or expired token", err)
+	}
+
+	return a.principal(claims)
+}
+
+// verify checks the token signature and standard claims and returns the claims
+func (a *JWTAuthenticator) verify(ctx context.Context, token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	hash, ok := signingAlgorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	key, err := a.keys.Key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	if err := verifySignature(header.Alg, key, hash, hasher.Sum(nil), signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid claims: %w", err)
+	}
+
+	if err := a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// validateClaims checks expiry, not-before, issuer and audience
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := time.Now()
+
+	exp, ok := numericClaim(claims, "exp")
+	if !ok {
+		return errors.New("missing exp claim")
+	}
+	if now.After(exp.Add(a.config.Leeway)) {
+		return errors.New("token expired")
+	}
+
+	if nbf, ok := numericClaim(claims, "nbf"); ok && now.Add(a.config.Leeway).Before(nbf) {
+		return errors.New("token not yet valid")
+	}
+
+	if a.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.config.Issuer {
+			return fmt.Errorf("unexpected issuer %q", iss)
+		}
+	}
+
+	if a.config.Audience != "" && !containsString(stringsClaim(claims["aud"]), a.config.Audience) {
+		return errors.New("token not issued for this audience")
+	}
+
+	return nil
+}
+
+// principal maps verified claims to a principal with roles and scopes
+func (a *JWTAuthenticator) principal(claims map[string]interface{}) (*models.Principal, error) {
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, apperrors.NewUnauthorizedError("Token has no subject", nil)
+	}
+
+	name := subject
+	for _, claim := range []string{"preferred_username", "email", "name"} {
+		if value, ok := claims[claim].(string); ok && value != "" {
+			name = value
+			break
+		}
+	}
+
+	tenantID := models.DefaultTenantID
+	if a.config.TenantClaim != "" {
+		tenantID, _ = lookupClaim(claims, a.config.TenantClaim).(string)
+		if tenantID == "" {
+			return nil, apperrors.NewUnauthorizedError("Token has no organization", nil)
+		}
+	}
+
+	roles := stringsClaim(lookupClaim(claims, a.config.RolesClaim))
+
+	scopes := append([]models.Scope(nil), a.config.DefaultScopes...)
+	for _, role := range roles {
+		for _, scope := range a.config.RoleScopes[role] {
+			if !containsScope(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+
+	return &models.Principal{
+		ID:       subject,
+		Name:     name,
+		Kind:     models.PrincipalUser,
+		TenantID: tenantID,
+		Roles:    roles,
+		Scopes:   scopes,
+	}, nil
+}
+
+// verifySignature checks the signature with the key type the algorithm requires
+func verifySignature(alg string, key crypto.PublicKey, hash crypto.Hash, digest, signature []byte) error {
+	switch alg[:2] {
+	case "RS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type does not match algorithm")
+		}
+		if err := rsa.VerifyPKCS1v15(rsaKey, hash, digest, signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	case "PS":
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("key type does not match algorithm")
+		}
+		if err := rsa.VerifyPSS(rsaKey, hash, digest, signature, nil); err != nil {
+			return errors.New("invalid signature")
+		}
+	case "ES":
+		ecKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("key type does not match algorithm")
+		}
+		size := (ecKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("invalid signature length")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(ecKey, digest, r, s) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment of a token
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// numericClaim returns a NumericDate claim as a time
+func numericClaim(claims map[string]interface{}, name string) (time.Time, bool) {
+	value, ok := claims[name].(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(value), 0), true
+}
+
+// lookupClaim follows a dotted path through nested claims
+func lookupClaim(claims map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+
+	var current interface{} = claims
+	for _, part := range strings.Split(path, ".") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[part]
+	}
+
+	return current
+}
+
+// stringsClaim reads a claim that is either a string array or a space-separated string
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// containsString reports whether values contains target
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// containsScope reports whether scopes contains target
+func containsScope(scopes []models.Scope, target models.Scope) bool {
+	for _, scope := range scopes {
+		if scope == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"image/internal/domain/models"
+	"image/pkg/logger"
+)
+
+// testKeys holds the signing keys published in the test key set
+type testKeys struct {
+	rsa   *rsa.PrivateKey
+	ec    *ecdsa.PrivateKey
+	other *rsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey, other: otherKey}
+}
+
+// jwks returns a JWKS document publishing the RSA key as kid and the EC key as "ec"
+func (k *testKeys) jwks(kid string) []byte {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	size := (k.ec.Curve.Params().BitSize + 7) / 8
+
+	doc := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA", "kid": kid, "use": "sig",
+				"n": encode(k.rsa.N.Bytes()),
+				"e": encode(big.NewInt(int64(k.rsa.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec", "crv": "P-256",
+				"x": encode(k.ec.X.FillBytes(make([]byte, size))),
+				"y": encode(k.ec.Y.FillBytes(make([]byte, size))),
+			},
+		},
+	}
+	data, _ := json.Marshal(doc)
+	return data
+}
+
+// sign returns a compact JWS of claims signed with key under the given algorithm and kid
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	if key == nil {
+		return input + "."
+	}
+
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(input))
+	sum := digest.Sum(nil)
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, sum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestAuthenticator(t *testing.T, keys *testKeys, config JWTConfig) *JWTAuthenticator {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keys.jwks("rsa"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	log := logger.New(logger.WithOutput(io.Discard))
+	keySet := NewKeySet(path, time.Hour, log)
+	if err := keySet.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTAuthenticator(keySet, config, log)
+}
+
+func TestJWTAuthenticatorAuthenticate(t *testing.T) {
+	keys := newTestKeys(t)
+	auth := newTestAuthenticator(t, keys, JWTConfig{
+		Issuer:     "https://issuer.example.com",
+		Audience:   "image-api",
+		Leeway:     30 * time.Second,
+		RolesClaim: "realm_access.roles",
+		RoleScopes: map[string][]models.Scope{"artist": {models.ScopeGenerate}},
+	})
+
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":          "user-1",
+			"iss":          "https://issuer.example.com",
+			"aud":          "image-api",
+			"exp":          now.Add(time.Hour).Unix(),
+			"realm_access": map[string]interface{}{"roles": []string{"artist"}},
+		}
+		for name, value := range overrides {
+			if value == nil {
+				delete(c, name)
+				continue
+			}
+			c[name] = value
+		}
+		return c
+	}
+
+	tamper := func(token string) string {
+		parts := strings.Split(token, ".")
+		payload, _ := json.Marshal(claims(map[string]interface{}{"sub": "admin"}))
+		parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+		return strings.Join(parts, ".")
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256 signature", sign(t, "RS256", "rsa", keys.rsa, claims(nil)), true},
+		{"ES256 signature", sign(t, "ES256", "ec", keys.ec, claims(nil)), true},
+		{"audience in list", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"aud": []string{"other", "image-api"}})), true},
+		{"expired within leeway", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"exp": now.Add(-10 * time.Second).Unix()})), true},
+		{"tampered claims", tamper(sign(t, "RS256", "rsa", keys.rsa, claims(nil))), false},
+		{"signed by another key", sign(t, "RS256", "rsa", keys.other, claims(nil)), false},
+		{"key type mismatch", sign(t, "ES256", "rsa", keys.ec, claims(nil)), false},
+		{"unsigned", sign(t, "none", "rsa", nil, claims(nil)), false},
+		{"symmetric algorithm", sign(t, "HS256", "rsa", keys.rsa, claims(nil)), false},
+		{"unknown key", sign(t, "RS256", "missing", keys.rsa, claims(nil)), false},
+		{"expired", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})), false},
+		{"missing expiry", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"exp": nil})), false},
+		{"not yet valid", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})), false},
+		{"wrong audience", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"aud": "other"})), false},
+		{"missing audience", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"aud": nil})), false},
+		{"wrong issuer", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"iss": "https://evil.example.com"})), false},
+		{"missing subject", sign(t, "RS256", "rsa", keys.rsa, claims(map[string]interface{}{"sub": nil})), false},
+		{"malformed", "not-a-token", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := auth.Authenticate(context.Background(), tt.token)
+			if tt.valid && err != nil {
+				t.Fatalf("expected token to be accepted, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatalf("expected token to be rejected, got principal %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTAuthenticatorPrincipal(t *testing.T) {
+	keys := newTestKeys(t)
+	auth := newTestAuthenticator(t, keys, JWTConfig{
+		RolesClaim:    "roles",
+		TenantClaim:   "org_id",
+		RoleScopes:    map[string][]models.Scope{"admin": {models.ScopeAdmin, models.ScopeGenerate}},
+		DefaultScopes: []models.Scope{models.ScopeGenerate},
+	})
+
+	token := sign(t, "RS256", "rsa", keys.rsa, map[string]interface{}{
+		"sub":                "user-1",
+		"preferred_username": "ada",
+		"org_id":             "acme",
+		"roles":              "admin viewer",
+		"exp":                time.Now().Add(time.Hour).Unix(),
+	})
+
+	principal, err := auth.Authenticate(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if principal.ID != "user-1" || principal.Name != "ada" || principal.TenantID != "acme" {
+		t.Errorf("unexpected principal %+v", principal)
+	}
+	if len(principal.Scopes) != 2 || principal.Scopes[0] != models.ScopeGenerate || principal.Scopes[1] != models.ScopeAdmin {
+		t.Errorf("expected generate and admin scopes without duplicates, got %v", principal.Scopes)
+	}
+
+	withoutTenant := sign(t, "RS256", "rsa", keys.rsa, map[string]interface{}{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if _, err := auth.Authenticate(context.Background(), withoutTenant); err == nil {
+		t.Error("expected a token without the tenant claim to be rejected")
+	}
+}
+
+func TestKeySetRefreshThrottling(t *testing.T) {
+	keys := newTestKeys(t)
+
+	var fetches atomic.Int32
+	var kid atomic.Value
+	kid.Store("old")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		w.Write(keys.jwks(kid.Load().(string)))
+	}))
+	defer server.Close()
+
+	keySet := NewKeySet(server.URL, time.Hour, logger.New(logger.WithOutput(io.Discard)))
+	if err := keySet.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown keys right after a load do not reach the provider
+	for i := 0; i < 5; i++ {
+		if _, err := keySet.Key(context.Background(), "forged"); err == nil {
+			t.Fatal("expected unknown key to be rejected")
+		}
+	}
+	if n := fetches.Load(); n != 1 {
+		t.Fatalf("expected unknown keys to be throttled, got %d fetches", n)
+	}
+
+	// Once the throttle has passed, a rotated key is picked up by a single shared reload
+	kid.Store("new")
+	keySet.mu.Lock()
+	keySet.attemptedAt = time.Now().Add(-2 * minRefreshInterval)
+	keySet.mu.Unlock()
+
+	if _, err := keySet.Key(context.Background(), "new"); err != nil {
+		t.Fatalf("expected rotated key to be found, got %v", err)
+	}
+	if n := fetches.Load(); n != 2 {
+		t.Fatalf("expected one reload for the rotated key, got %d fetches", n-1)
+	}
+
+	// A stale set keeps serving cached keys while it reloads in the background
+	keySet.mu.Lock()
+	keySet.fetchedAt = time.Now().Add(-2 * time.Hour)
+	keySet.attemptedAt = time.Now().Add(-2 * minRefreshInterval)
+	keySet.mu.Unlock()
+
+	if _, err := keySet.Key(context.Background(), "ec"); err != nil {
+		t.Fatalf("expected cached key while stale, got %v", err)
+	}
+	keySet.mu.RLock()
+	done := keySet.refreshing
+	keySet.mu.RUnlock()
+	if done != nil {
+		<-done
+	}
+	if n := fetches.Load(); n != 3 {
+		t.Fatalf("expected a background reload of the stale set, got %d fetches", n)
+	}
+}
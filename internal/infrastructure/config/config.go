@@ -1,19 +1,54 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	"image/internal/domain/models"
+	"image/internal/infrastructure/cors"
+	"image/internal/infrastructure/ratelimit"
+	"image/internal/infrastructure/tracing"
+	"image/pkg/logger"
+	"image/pkg/secret"
+
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	Server    ServerConfig
+	Security  SecurityConfig
+	CORS      cors.Config
 	ModelsLab ModelsLabConfig
+	Polling   PollingConfig
+	Limits    LimitsConfig
+	Batch     BatchConfig
+	Sweep     SweepConfig
+	Storage   StorageConfig
+	Auth      AuthConfig
+	RateLimit RateLimitConfig
+	Usage     UsageConfig
+	Audit     AuditConfig
+	Logging   LoggingConfig
+	Metrics   MetricsConfig
+	Tracing   tracing.Config
+	Health    HealthConfig
+	Pricing   map[string]ModelPricing
+	Tenants   map[string]TenantConfig
+	// RolePolicies restrict what principals may generate, keyed by role
+	RolePolicies map[string]models.RolePolicy
+	// Models are the catalog's model definitions, registered in place of built-in ones
+	Models []ModelDefinition
+	// Settings holds the effective value and source of every setting, ordered by key
+	Settings []Setting
 }
 
 // ServerConfig holds HTTP server configuration
@@ -21,59 +56,428 @@ type ServerConfig struct {
 	Port         int
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// DrainTimeout is how long in-flight generations may run after a shutdown signal
+	DrainTimeout time.Duration
+	// ShutdownTimeout is how long open connections may take to close after draining
+	ShutdownTimeout time.Duration
+	// MaxBodyBytes bounds request bodies, with RouteMaxBodyBytes overriding it per route
+	MaxBodyBytes      int64
+	RouteMaxBodyBytes map[string]int64
+	// HandlerTimeout bounds how long a handler may run, with RouteTimeouts overriding it
+	// per route. Route keys are the handler names registered with the server.
+	HandlerTimeout time.Duration
+	RouteTimeouts  map[string]time.Duration
+}
+
+// SecurityConfig holds the security headers set on every response. An HSTSMaxAge of zero
+// leaves out Strict-Transport-Security.
+type SecurityConfig struct {
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	ContentSecurityPolicy string
 }
 
-// ModelsLabConfig holds ModelsLab API configuration
+// ModelsLabConfig holds ModelsLab API configuration. Keys lists the pooled API keys,
+// including APIKey; KeysFile adds keys that are read again on every reload.
 type ModelsLabConfig struct {
-	APIKey     string
-	BaseURL    string
-	MaxRetries int
+	APIKey         secret.Secret
+	Keys           []models.UpstreamKey
+	KeysFile       string
+	KeyStrategy    string
+	KeyCooldown    time.Duration
+	BaseURL        string
+	Timeout        time.Duration
+	MaxRetries     int
+	CancelEndpoint string
+}
+
+// PollingConfig holds configuration for polling processing jobs
+type PollingConfig struct {
+	MaxWait      time.Duration
+	BaseInterval time.Duration
+	MaxInterval  time.Duration
+	Multiplier   float64
+	Endpoints    []string
+}
+
+// LimitsConfig holds the bounds every generation request must stay within, on top of
+// the limits of the requested model
+type LimitsConfig struct {
+	MaxPixels  int
+	MaxSamples int
+}
+
+// BatchConfig holds batch generation configuration
+type BatchConfig struct {
+	MaxItems       int
+	MaxConcurrency int
+}
+
+// SweepConfig holds parameter sweep configuration
+type SweepConfig struct {
+	MaxCells int
+}
+
+// StorageConfig holds configuration for stored generation records. PendingJobsFile
+// records the upstream jobs still processing when the server stops.
+type StorageConfig struct {
+	MaxGenerations  int
+	PendingJobsFile string
+}
+
+// AuthConfig holds API authentication configuration
+type AuthConfig struct {
+	Enabled      bool
+	KeysFile     string
+	BootstrapKey secret.Secret
+	JWT          JWTConfig
+}
+
+// JWTConfig holds configuration for validating JWTs from the identity provider.
+// JWT authentication is disabled when JWKS is empty.
+type JWTConfig struct {
+	JWKS            string
+	RefreshInterval time.Duration
+	Issuer          string
+	Audience        string
+	Leeway          time.Duration
+	RolesClaim      string
+	TenantClaim     string
+	RoleScopes      map[string][]string
+	DefaultScopes   []string
+}
+
+// RateLimitConfig holds per-caller rate limits. Route keys are the handler names
+// registered with the server (e.g. text2img, batch); model keys are model IDs.
+type RateLimitConfig struct {
+	Enabled bool
+	Default *ratelimit.Rule
+	Routes  map[string]ratelimit.Rule
+	Models  map[string]ratelimit.Rule
+}
+
+// UsageConfig holds usage metering and quota configuration. Quotas of zero are unlimited.
+type UsageConfig struct {
+	File              string
+	DailyImageQuota   int
+	MonthlyImageQuota int
+	// PrincipalImageQuotas are keyed by tenant/principal
+	PrincipalImageQuotas map[string]ImageQuota
+}
+
+// AuditConfig holds audit log configuration
+type AuditConfig struct {
+	File string
+	// HMACKey keys the hash chain so it cannot be recomputed after tampering
+	HMACKey secret.Secret
 }
 
-// New creates a new Config instance with values from environment variables
-func New() (*Config, error) {
+// LoggingConfig holds log output configuration. RedactFields adds field names whose
+// values are redacted on top of the logger's defaults.
+type LoggingConfig struct {
+	Level         slog.Level
+	Format        string
+	RedactFields  []string
+	RedactPrompts bool
+}
+
+// HealthConfig holds readiness probe configuration
+type HealthConfig struct {
+	ProbeTimeout time.Duration
+	// ModelsLabCacheTTL is how long a ModelsLab reachability result is reused
+	ModelsLabCacheTTL time.Duration
+	// MinFreeDisk is the free space in bytes the data directory needs to be ready
+	MinFreeDisk uint64
+}
+
+// MetricsConfig holds Prometheus metrics configuration
+type MetricsConfig struct {
+	Enabled bool
+}
+
+// ImageQuota holds daily and monthly image quotas for a single principal
+type ImageQuota struct {
+	Daily   int
+	Monthly int
+}
+
+// ModelPricing holds the pricing rules of a model in credits
+type ModelPricing struct {
+	BaseCost          float64
+	PerSample         float64
+	PerStep           float64
+	UpscaleMultiplier float64
+}
+
+// TenantConfig holds the overrides of a single tenant, as read from the tenants file
+type TenantConfig struct {
+	// AllowedModels lists the model IDs the tenant may use; empty allows every model
+	AllowedModels []string `json:"allowed_models"`
+	// Defaults holds request defaults keyed by model ID
+	Defaults          map[string]models.RequestDefaults `json:"defaults"`
+	DailyImageQuota   int                               `json:"daily_image_quota"`
+	MonthlyImageQuota int                               `json:"monthly_image_quota"`
+}
+
+// Option defines a function type for configuration loading
+type Option func(*options)
+
+// options holds the sources New reads besides the environment
+type options struct {
+	file      string
+	overrides map[string]string
+}
+
+// WithFile reads settings from a config file, TOML when its name ends in .toml and YAML
+// otherwise. Without it, or with an empty path, the file named by the CONFIG_FILE
+// environment variable is read, if any.
+func WithFile(path string) Option {
+	return func(o *options) {
+		if path != "" {
+			o.file = path
+		}
+	}
+}
+
+// WithOverrides sets settings from the command line, keyed by their environment variable
+// names or dotted file paths, e.g. SERVER_PORT or server.port. They take precedence over
+// the environment and the config file.
+func WithOverrides(overrides map[string]string) Option {
+	return func(o *options) {
+		o.overrides = overrides
+	}
+}
+
+// ModelDefinition describes a model of the catalog file and the limits of its requests.
+// Requests are still bounded by the API-wide limits of Text2ImgRequest.
+type ModelDefinition struct {
+	ID                  string   `yaml:"id"`
+	MaxWidth            int      `yaml:"max_width"`
+	MaxHeight           int      `yaml:"max_height"`
+	MaxSamples          int      `yaml:"max_samples"`
+	MinInferenceSteps   int      `yaml:"min_inference_steps"`
+	MaxInferenceSteps   int      `yaml:"max_inference_steps"`
+	SupportedSchedulers []string `yaml:"supported_schedulers"`
+	MinGuidanceScale    float64  `yaml:"min_guidance_scale"`
+	MaxGuidanceScale    float64  `yaml:"max_guidance_scale"`
+	SupportsUpscale     bool     `yaml:"supports_upscale"`
+	SupportsTomeSD      bool     `yaml:"supports_tomesd"`
+	SupportsKarras      bool     `yaml:"supports_karras"`
+}
+
+// Capabilities converts the definition to the capabilities of a registered model
+func (m ModelDefinition) Capabilities() models.ModelCapabilities {
+	return models.ModelCapabilities{
+		MaxWidth:            m.MaxWidth,
+		MaxHeight:           m.MaxHeight,
+		MaxSamples:          m.MaxSamples,
+		MinInferenceSteps:   m.MinInferenceSteps,
+		MaxInferenceSteps:   m.MaxInferenceSteps,
+		SupportedSchedulers: m.SupportedSchedulers,
+		MinGuidanceScale:    m.MinGuidanceScale,
+		MaxGuidanceScale:    m.MaxGuidanceScale,
+		SupportsUpscale:     m.SupportsUpscale,
+		SupportsTomeSD:      m.SupportsTomeSD,
+		SupportsKarras:      m.SupportsKarras,
+	}
+}
+
+// New creates a new Config instance. Every setting is named after its environment variable
+// and resolved from the command-line overrides, then the environment, then the config file,
+// where nested sections name settings too (server: {port: 8080} sets SERVER_PORT), then its
+// default. All invalid settings are reported together.
+func New(opts ...Option) (*Config, error) {
 	// Load .env file if it exists
 	if err := loadEnvFile(); err != nil {
 		return nil, fmt.Errorf("failed to load .env file: %w", err)
 	}
 
-	port, err := strconv.Atoi(getEnvOrDefault("SERVER_PORT", "8080"))
+	o := options{file: os.Getenv("CONFIG_FILE")}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	l, err := newLoader(o.file, o.overrides)
 	if err != nil {
-		return nil, fmt.Errorf("invalid server port: %w", err)
+		return nil, err
 	}
 
-	readTimeout, err := time.ParseDuration(getEnvOrDefault("SERVER_READ_TIMEOUT", "5s"))
+	cfg := l.load()
+	l.validate(cfg)
+	l.checkUnknown()
+	if err := l.err(); err != nil {
+		return nil, err
+	}
+
+	cfg.Settings = l.effective()
+	return cfg, nil
+}
+
+// load reads every setting into a Config, recording invalid values in the loader
+func (l *loader) load() *Config {
+	apiKey := l.secret("MODELSLAB_API_KEY")
+	upstreamKeys, err := parseUpstreamKeys(l.get("MODELSLAB_API_KEYS", ""))
+	l.markSecret("MODELSLAB_API_KEYS")
 	if err != nil {
-		return nil, fmt.Errorf("invalid read timeout: %w", err)
+		l.fail("MODELSLAB_API_KEYS", "%v", err)
+	}
+	if !apiKey.IsEmpty() {
+		upstreamKeys = append([]models.UpstreamKey{{Name: "default", Key: apiKey}}, upstreamKeys...)
 	}
 
-	writeTimeout, err := time.ParseDuration(getEnvOrDefault("SERVER_WRITE_TIMEOUT", "10s"))
+	roleScopes, err := parseRoleScopes(l.get("AUTH_JWT_ROLE_SCOPES", "admin=admin"))
 	if err != nil {
-		return nil, fmt.Errorf("invalid write timeout: %w", err)
+		l.fail("AUTH_JWT_ROLE_SCOPES", "%v", err)
 	}
 
-	maxRetries, err := strconv.Atoi(getEnvOrDefault("MODELSLAB_MAX_RETRIES", "3"))
+	principalQuotas, err := parseImageQuotas(l.get("QUOTA_PRINCIPALS", ""))
 	if err != nil {
-		return nil, fmt.Errorf("invalid max retries: %w", err)
+		l.fail("QUOTA_PRINCIPALS", "%v", err)
 	}
 
-	apiKey := os.Getenv("MODELSLAB_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("MODELSLAB_API_KEY environment variable is required")
+	pricing, err := parsePricing(l.get("PRICING_MODELS",
+		"flux=base:0,sample:1,step:0.05,upscale:1;midjourney=base:0,sample:2,step:0.1,upscale:1.5"))
+	if err != nil {
+		l.fail("PRICING_MODELS", "%v", err)
+	}
+
+	tenants, err := loadTenants(l.get("TENANTS_FILE", ""))
+	if err != nil {
+		l.fail("TENANTS_FILE", "%v", err)
+	}
+
+	var rolePolicies map[string]models.RolePolicy
+	if err := readJSONConfig(l.get("RBAC_POLICIES_FILE", ""), &rolePolicies); err != nil {
+		l.fail("RBAC_POLICIES_FILE", "%v", err)
+	}
+
+	catalog, err := loadModelCatalog(l.get("MODELS_CATALOG_FILE", ""))
+	if err != nil {
+		l.fail("MODELS_CATALOG_FILE", "%v", err)
+	}
+
+	logLevel, err := logger.ParseLevel(l.get("LOG_LEVEL", "info"))
+	if err != nil {
+		l.fail("LOG_LEVEL", "%v", err)
+	}
+
+	routeBodyLimits, err := parseRouteBodyLimits(l.get("SERVER_ROUTE_MAX_BODY_KB", "text2img=64;estimate=64"))
+	if err != nil {
+		l.fail("SERVER_ROUTE_MAX_BODY_KB", "%v", err)
+	}
+
+	// Generation routes wait for upstream jobs, so they outlast the default retries and polling
+	// of a generation (4m33s) times the rounds of a full batch (8) or sweep (32)
+	routeTimeouts, err := parseRouteTimeouts(l.get("SERVER_ROUTE_TIMEOUTS",
+		"text2img=5m;reproduce=5m;batch=40m;sweep=150m;usageexport=1m;tenantsusageexport=1m;auditexport=1m"))
+	if err != nil {
+		l.fail("SERVER_ROUTE_TIMEOUTS", "%v", err)
 	}
 
 	return &Config{
 		Server: ServerConfig{
-			Port:         port,
-			ReadTimeout:  readTimeout,
-			WriteTimeout: writeTimeout,
+			Port:              l.int("SERVER_PORT", "8080"),
+			ReadTimeout:       l.duration("SERVER_READ_TIMEOUT", "5s"),
+			WriteTimeout:      l.duration("SERVER_WRITE_TIMEOUT", "10s"),
+			DrainTimeout:      l.duration("SERVER_DRAIN_TIMEOUT", "30s"),
+			ShutdownTimeout:   l.duration("SERVER_SHUTDOWN_TIMEOUT", "10s"),
+			MaxBodyBytes:      int64(l.int("SERVER_MAX_BODY_KB", "1024")) << 10,
+			RouteMaxBodyBytes: routeBodyLimits,
+			HandlerTimeout:    l.duration("SERVER_HANDLER_TIMEOUT", "10s"),
+			RouteTimeouts:     routeTimeouts,
 		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            l.duration("SECURITY_HSTS_MAX_AGE", "8760h"),
+			HSTSIncludeSubdomains: l.bool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", "false"),
+			ContentSecurityPolicy: l.get("SECURITY_CONTENT_SECURITY_POLICY", "default-src 'none'; frame-ancestors 'none'"),
+		},
+		CORS: l.corsPolicy(),
 		ModelsLab: ModelsLabConfig{
-			APIKey:     apiKey,
-			BaseURL:    getEnvOrDefault("MODELSLAB_BASE_URL", "https://modelslab.com/api/v6"),
-			MaxRetries: maxRetries,
+			APIKey:         apiKey,
+			Keys:           upstreamKeys,
+			KeysFile:       l.get("MODELSLAB_API_KEYS_FILE", ""),
+			KeyStrategy:    l.get("MODELSLAB_KEY_STRATEGY", models.KeyStrategyRoundRobin),
+			KeyCooldown:    l.duration("MODELSLAB_KEY_COOLDOWN", "1m"),
+			BaseURL:        l.get("MODELSLAB_BASE_URL", "https://modelslab.com/api/v6"),
+			Timeout:        l.duration("MODELSLAB_TIMEOUT", "30s"),
+			MaxRetries:     l.int("MODELSLAB_MAX_RETRIES", "3"),
+			CancelEndpoint: l.get("MODELSLAB_CANCEL_ENDPOINT", ""),
+		},
+		Polling: PollingConfig{
+			MaxWait:      l.duration("MODELSLAB_POLL_MAX_WAIT", "3m"),
+			BaseInterval: l.duration("MODELSLAB_POLL_BASE_INTERVAL", "1s"),
+			MaxInterval:  l.duration("MODELSLAB_POLL_MAX_INTERVAL", "15s"),
+			Multiplier:   l.float("MODELSLAB_POLL_MULTIPLIER", "1.5"),
+			Endpoints:    splitList(l.get("MODELSLAB_POLL_ENDPOINTS", "/status/{id},/images/status/{id},/images/text2img/{id}")),
+		},
+		Limits: LimitsConfig{
+			MaxPixels:  l.int("LIMITS_MAX_PIXELS", "1048576"),
+			MaxSamples: l.int("LIMITS_MAX_SAMPLES", "4"),
+		},
+		Batch: BatchConfig{
+			MaxItems:       l.int("BATCH_MAX_ITEMS", "16"),
+			MaxConcurrency: l.int("BATCH_MAX_CONCURRENCY", "2"),
+		},
+		Sweep: SweepConfig{
+			MaxCells: l.int("SWEEP_MAX_CELLS", "64"),
 		},
-	}, nil
+		Storage: StorageConfig{
+			MaxGenerations:  l.int("STORAGE_MAX_GENERATIONS", "1000"),
+			PendingJobsFile: l.get("STORAGE_PENDING_JOBS_FILE", "data/pending_jobs.json"),
+		},
+		Auth: AuthConfig{
+			Enabled:      l.bool("AUTH_ENABLED", "true"),
+			KeysFile:     l.get("AUTH_KEYS_FILE", "data/api_keys.json"),
+			BootstrapKey: l.secret("AUTH_BOOTSTRAP_KEY"),
+			JWT: JWTConfig{
+				JWKS:            l.get("AUTH_JWT_JWKS", ""),
+				RefreshInterval: l.duration("AUTH_JWT_JWKS_REFRESH", "1h"),
+				Issuer:          l.get("AUTH_JWT_ISSUER", ""),
+				Audience:        l.get("AUTH_JWT_AUDIENCE", ""),
+				Leeway:          l.duration("AUTH_JWT_LEEWAY", "30s"),
+				RolesClaim:      l.get("AUTH_JWT_ROLES_CLAIM", "roles"),
+				TenantClaim:     l.get("AUTH_JWT_TENANT_CLAIM", ""),
+				RoleScopes:      roleScopes,
+				DefaultScopes:   splitList(l.get("AUTH_JWT_DEFAULT_SCOPES", "generate,read-history")),
+			},
+		},
+		RateLimit: l.rateLimits(),
+		Usage: UsageConfig{
+			File:                 l.get("USAGE_FILE", "data/usage.jsonl"),
+			DailyImageQuota:      l.int("QUOTA_DAILY_IMAGES", "0"),
+			MonthlyImageQuota:    l.int("QUOTA_MONTHLY_IMAGES", "0"),
+			PrincipalImageQuotas: principalQuotas,
+		},
+		Audit: AuditConfig{
+			File:    l.get("AUDIT_FILE", "data/audit.jsonl"),
+			HMACKey: l.secret("AUDIT_HMAC_KEY"),
+		},
+		Logging: LoggingConfig{
+			Level:         logLevel,
+			Format:        l.get("LOG_FORMAT", logger.FormatJSON),
+			RedactFields:  splitList(l.get("LOG_REDACT_FIELDS", "")),
+			RedactPrompts: l.bool("LOG_REDACT_PROMPTS", "false"),
+		},
+		Metrics: MetricsConfig{
+			Enabled: l.bool("METRICS_ENABLED", "true"),
+		},
+		Health: HealthConfig{
+			ProbeTimeout:      l.duration("HEALTH_PROBE_TIMEOUT", "2s"),
+			ModelsLabCacheTTL: l.duration("HEALTH_MODELSLAB_CACHE_TTL", "30s"),
+			MinFreeDisk:       l.uint("HEALTH_MIN_FREE_DISK_MB", "100") << 20,
+		},
+		Tracing: tracing.Config{
+			Exporter:     l.get("TRACING_EXPORTER", tracing.ExporterNone),
+			OTLPEndpoint: l.get("TRACING_OTLP_ENDPOINT", ""),
+			OTLPInsecure: l.bool("TRACING_OTLP_INSECURE", "false"),
+			ServiceName:  l.get("TRACING_SERVICE_NAME", "image-api"),
+			SampleRatio:  l.float("TRACING_SAMPLE_RATIO", "1"),
+		},
+		Models:       catalog,
+		Pricing:      pricing,
+		Tenants:      tenants,
+		RolePolicies: rolePolicies,
+	}
 }
 
 // loadEnvFile loads environment variables from .env file
@@ -104,10 +508,297 @@ func loadEnvFile() error {
 	return nil
 }
 
-// getEnvOrDefault returns the value of an environment variable or a default value if not set
-func getEnvOrDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// splitList splits a comma-separated value into its trimmed, non-empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseRoleScopes parses "role=scope,scope;role=scope" into a map of role to scopes
+func parseRoleScopes(value string) (map[string][]string, error) {
+	return parseNamedLists(value, "role=scope[,scope]")
+}
+
+// parseNamedLists parses "name=item,item;name=item" into a map of name to items, naming
+// the expected format in errors
+func parseNamedLists(value, format string) (map[string][]string, error) {
+	lists := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, items, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("expected %s but got %q", format, entry)
+		}
+		lists[strings.TrimSpace(name)] = splitList(items)
+	}
+	return lists, nil
+}
+
+// corsPolicy reads the cross-origin policy
+func (l *loader) corsPolicy() cors.Config {
+	cfg := cors.Config{
+		AllowedOrigins:   splitList(l.get("CORS_ALLOWED_ORIGINS", "http://localhost:5173")),
+		AllowedMethods:   splitList(l.get("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS")),
+		AllowedHeaders:   splitList(l.get("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-API-Key,X-Requested-With,X-Request-ID")),
+		ExposedHeaders:   splitList(l.get("CORS_EXPOSED_HEADERS", "X-Request-ID,Retry-After")),
+		AllowCredentials: l.bool("CORS_ALLOW_CREDENTIALS", "true"),
+		MaxAge:           l.duration("CORS_MAX_AGE", "1h"),
+	}
+
+	var err error
+	if cfg.RouteMethods, err = parseNamedLists(l.get("CORS_ROUTE_METHODS", ""), "route=method[,method]"); err != nil {
+		l.fail("CORS_ROUTE_METHODS", "%v", err)
+	}
+	if cfg.RouteHeaders, err = parseNamedLists(l.get("CORS_ROUTE_HEADERS", ""), "route=header[,header]"); err != nil {
+		l.fail("CORS_ROUTE_HEADERS", "%v", err)
+	}
+
+	return cfg
+}
+
+// rateLimits reads the rate limit rules; a default rule of "off" leaves other routes unlimited
+func (l *loader) rateLimits() RateLimitConfig {
+	cfg := RateLimitConfig{Enabled: l.bool("RATE_LIMIT_ENABLED", "true")}
+
+	if value := l.get("RATE_LIMIT_DEFAULT", "120/m"); value != "off" {
+		rule, err := ratelimit.ParseRule(value)
+		if err != nil {
+			l.fail("RATE_LIMIT_DEFAULT", "%v", err)
+		}
+		cfg.Default = &rule
+	}
+
+	var err error
+	if cfg.Routes, err = parseRateLimitRules(l.get("RATE_LIMIT_ROUTES", "text2img=20/m;batch=5/m;sweep=2/m;reproduce=20/m")); err != nil {
+		l.fail("RATE_LIMIT_ROUTES", "%v", err)
+	}
+
+	if cfg.Models, err = parseRateLimitRules(l.get("RATE_LIMIT_MODELS", "")); err != nil {
+		l.fail("RATE_LIMIT_MODELS", "%v", err)
+	}
+
+	return cfg
+}
+
+// parseRateLimitRules parses "name=rule;name=rule" into a map of name to rule
+func parseRateLimitRules(value string) (map[string]ratelimit.Rule, error) {
+	rules := make(map[string]ratelimit.Rule)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, ruleValue, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("expected name=rule but got %q", entry)
+		}
+		rule, err := ratelimit.ParseRule(ruleValue)
+		if err != nil {
+			return nil, err
+		}
+		rules[strings.TrimSpace(name)] = rule
+	}
+	return rules, nil
+}
+
+// parseRouteBodyLimits parses "route=kb;route=kb" into a map of route to body limit in bytes
+func parseRouteBodyLimits(value string) (map[string]int64, error) {
+	limits := make(map[string]int64)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, kb, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(route) == "" {
+			return nil, fmt.Errorf("expected route=kb but got %q", entry)
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(kb), 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid body limit in %q; expected a positive number of KB", entry)
+		}
+		limits[strings.TrimSpace(route)] = n << 10
+	}
+	return limits, nil
+}
+
+// parseRouteTimeouts parses "route=duration;route=duration" into a map of route to timeout
+func parseRouteTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, timeout, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(route) == "" {
+			return nil, fmt.Errorf("expected route=duration but got %q", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(timeout))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid timeout in %q; expected a positive duration such as 30s", entry)
+		}
+		timeouts[strings.TrimSpace(route)] = d
+	}
+	return timeouts, nil
+}
+
+// parseImageQuotas parses "tenant/principal=daily/monthly;principal=daily/monthly" into
+// quotas keyed by tenant/principal. Principals given without a tenant belong to the default
+// tenant, since principal IDs such as JWT subjects are only unique within their tenant.
+func parseImageQuotas(value string) (map[string]ImageQuota, error) {
+	quotas := make(map[string]ImageQuota)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		principal, limits, ok := strings.Cut(entry, "=")
+		daily, monthly, hasMonthly := strings.Cut(limits, "/")
+		principal = strings.TrimSpace(principal)
+		if !strings.Contains(principal, "/") {
+			principal = models.DefaultTenantID + "/" + principal
+		}
+		tenantID, principalID, _ := strings.Cut(principal, "/")
+		if !ok || !hasMonthly || tenantID == "" || principalID == "" {
+			return nil, fmt.Errorf("expected [tenant/]principal=daily/monthly but got %q", entry)
+		}
+		quota := ImageQuota{}
+		var err error
+		if quota.Daily, err = strconv.Atoi(strings.TrimSpace(daily)); err != nil {
+			return nil, fmt.Errorf("invalid daily quota in %q", entry)
+		}
+		if quota.Monthly, err = strconv.Atoi(strings.TrimSpace(monthly)); err != nil {
+			return nil, fmt.Errorf("invalid monthly quota in %q", entry)
+		}
+		quotas[principal] = quota
+	}
+	return quotas, nil
+}
+
+// parsePricing parses "model=base:N,sample:N,step:N,upscale:N;model=..." into pricing rules.
+// Omitted components default to zero, except upscale which defaults to 1.
+func parsePricing(value string) (map[string]ModelPricing, error) {
+	pricing := make(map[string]ModelPricing)
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		model, components, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("expected model=component:value[,...] but got %q", entry)
+		}
+		rules := ModelPricing{UpscaleMultiplier: 1}
+		for _, component := range splitList(components) {
+			name, amount, ok := strings.Cut(component, ":")
+			if !ok {
+				return nil, fmt.Errorf("expected component:value but got %q", component)
+			}
+			number, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+			if err != nil || number < 0 {
+				return nil, fmt.Errorf("invalid amount in %q", component)
+			}
+			switch strings.TrimSpace(name) {
+			case "base":
+				rules.BaseCost = number
+			case "sample":
+				rules.PerSample = number
+			case "step":
+				rules.PerStep = number
+			case "upscale":
+				rules.UpscaleMultiplier = number
+			default:
+				return nil, fmt.Errorf("unknown pricing component %q", name)
+			}
+		}
+		pricing[strings.TrimSpace(model)] = rules
+	}
+	return pricing, nil
+}
+
+// loadTenants reads tenant overrides keyed by tenant ID from a JSON file.
+// An empty path configures no overrides.
+func loadTenants(path string) (map[string]TenantConfig, error) {
+	var tenants map[string]TenantConfig
+	if err := readJSONConfig(path, &tenants); err != nil {
+		return nil, err
+	}
+
+	for id, tenant := range tenants {
+		if id == "" {
+			return nil, fmt.Errorf("tenant ID cannot be empty")
+		}
+		if tenant.DailyImageQuota < 0 || tenant.MonthlyImageQuota < 0 {
+			return nil, fmt.Errorf("tenant %s has a negative quota", id)
+		}
+	}
+
+	return tenants, nil
+}
+
+// loadModelCatalog reads model definitions from a YAML or JSON file listing them under
+// models. An empty path defines no models, leaving only the built-in ones.
+func loadModelCatalog(path string) ([]ModelDefinition, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog struct {
+		Models []ModelDefinition `yaml:"models"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return catalog.Models, nil
+}
+
+// readJSONConfig decodes the JSON file at path into v. An empty path leaves v untouched.
+func readJSONConfig(path string, v interface{}) error {
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// parseUpstreamKeys parses "name=key,key,..." into pooled API keys; unnamed keys are numbered
+func parseUpstreamKeys(value string) ([]models.UpstreamKey, error) {
+	var keys []models.UpstreamKey
+	for i, entry := range splitList(value) {
+		key := models.UpstreamKey{Name: fmt.Sprintf("key-%d", i+1), Key: secret.Secret(entry)}
+		if name, value, ok := strings.Cut(entry, "="); ok {
+			if strings.TrimSpace(name) == "" || strings.TrimSpace(value) == "" {
+				return nil, fmt.Errorf("expected name=key but got %q", entry)
+			}
+			key = models.UpstreamKey{Name: strings.TrimSpace(name), Key: secret.Secret(strings.TrimSpace(value))}
+		}
+		keys = append(keys, key)
 	}
-	return defaultValue
+	return keys, nil
 }
@@ -0,0 +1,285 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"image/pkg/secret"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a setting's value can come from, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Setting is the effective value of a single setting and where it came from
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	// Secret settings are redacted when the configuration is printed
+	Secret bool
+}
+
+// loader resolves settings from command-line overrides, then environment variables, then
+// the config file, then defaults. It records every setting it resolves so the effective
+// configuration can be printed, and collects every invalid value instead of stopping at
+// the first one.
+type loader struct {
+	file      map[string]string
+	fileName  string
+	overrides map[string]string
+	settings  map[string]Setting
+	errs      []string
+	// failed holds the keys that already have a problem recorded
+	failed map[string]bool
+}
+
+// newLoader creates a loader over the settings of the config file at path, if any
+func newLoader(path string, overrides map[string]string) (*loader, error) {
+	l := &loader{
+		file:      make(map[string]string),
+		fileName:  path,
+		overrides: make(map[string]string, len(overrides)),
+		settings:  make(map[string]Setting),
+		failed:    make(map[string]bool),
+	}
+
+	for key, value := range overrides {
+		l.overrides[settingKey(key)] = value
+	}
+
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	// Files ending in .toml are TOML; anything else is read as YAML
+	var root map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &root)
+	} else {
+		err = yaml.Unmarshal(data, &root)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+	if err := flatten("", root, l.file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return l, nil
+}
+
+// flatten turns nested sections into settings named like their environment variables, so
+// that server: {port: 8080} sets SERVER_PORT. Lists become comma-separated values.
+func flatten(prefix string, section map[string]interface{}, out map[string]string) error {
+	for name, value := range section {
+		key := settingKey(name)
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(key, value, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}:
+					return fmt.Errorf("%s: lists may only hold plain values", key)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case []map[string]interface{}:
+			// TOML arrays of tables
+			return fmt.Errorf("%s: lists may only hold plain values", key)
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(value)
+		}
+	}
+	return nil
+}
+
+// settingKey normalizes a setting name such as server.read-timeout to SERVER_READ_TIMEOUT
+func settingKey(name string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(strings.TrimSpace(name)))
+}
+
+// lookup returns the value of key from the highest-precedence source that sets it
+func (l *loader) lookup(key string) (string, string, bool) {
+	if value, ok := l.overrides[key]; ok {
+		return value, SourceFlag, true
+	}
+	// A variable set to an empty string still overrides the file
+	if value, ok := os.LookupEnv(key); ok {
+		return value, SourceEnv, true
+	}
+	if value, ok := l.file[key]; ok {
+		return value, SourceFile, true
+	}
+	return "", SourceDefault, false
+}
+
+// get returns the value of key, or defaultValue when no source sets it
+func (l *loader) get(key, defaultValue string) string {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value = defaultValue
+	}
+	l.settings[key] = Setting{Key: key, Value: value, Source: source}
+	return value
+}
+
+// secret returns a secret from key, or from the file named by key_FILE as mounted by
+// Docker and Kubernetes secrets. Setting both is an error.
+func (l *loader) secret(key string) secret.Secret {
+	value := l.get(key, "")
+	setting := l.settings[key]
+	setting.Secret = true
+	l.settings[key] = setting
+
+	path := l.get(key+"_FILE", "")
+	if path == "" {
+		return secret.Secret(value)
+	}
+	if value != "" {
+		l.fail(key, "only one of %s and %s_FILE may be set", key, key)
+		return ""
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		l.fail(key+"_FILE", "failed to read: %v", err)
+		return ""
+	}
+	return secret.Secret(strings.TrimSpace(string(data)))
+}
+
+// markSecret redacts a setting holding secrets when the configuration is printed
+func (l *loader) markSecret(key string) {
+	if setting, ok := l.settings[key]; ok {
+		setting.Secret = true
+		l.settings[key] = setting
+	}
+}
+
+// int returns key parsed as an integer
+func (l *loader) int(key, defaultValue string) int {
+	value := l.get(key, defaultValue)
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		l.fail(key, "expected an integer but got %q", value)
+	}
+	return n
+}
+
+// uint returns key parsed as a non-negative integer
+func (l *loader) uint(key, defaultValue string) uint64 {
+	value := l.get(key, defaultValue)
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		l.fail(key, "expected a non-negative integer but got %q", value)
+	}
+	return n
+}
+
+// float returns key parsed as a number
+func (l *loader) float(key, defaultValue string) float64 {
+	value := l.get(key, defaultValue)
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		l.fail(key, "expected a number but got %q", value)
+	}
+	return n
+}
+
+// bool returns key parsed as a boolean
+func (l *loader) bool(key, defaultValue string) bool {
+	value := l.get(key, defaultValue)
+	b, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		l.fail(key, "expected true or false but got %q", value)
+	}
+	return b
+}
+
+// duration returns key parsed as a duration such as 30s or 5m
+func (l *loader) duration(key, defaultValue string) time.Duration {
+	value := l.get(key, defaultValue)
+	d, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		l.fail(key, "expected a duration such as 30s but got %q", value)
+	}
+	return d
+}
+
+// fail records an invalid setting
+func (l *loader) fail(key, format string, args ...interface{}) {
+	l.errs = append(l.errs, key+": "+fmt.Sprintf(format, args...))
+	l.failed[key] = true
+}
+
+// invalid records a setting that is out of range, unless it already failed to parse
+func (l *loader) invalid(key, format string, args ...interface{}) {
+	if !l.failed[key] {
+		l.fail(key, format, args...)
+	}
+}
+
+// checkUnknown reports settings given in the config file or on the command line that
+// were never read, which are most likely misspelled
+func (l *loader) checkUnknown() {
+	for key := range l.overrides {
+		if _, ok := l.settings[key]; !ok {
+			l.fail(key, "unknown setting given on the command line")
+		}
+	}
+	for key := range l.file {
+		if _, ok := l.settings[key]; !ok {
+			l.fail(key, "unknown setting in %s", l.fileName)
+		}
+	}
+}
+
+// err returns every problem found, one per line, or nil when the configuration is valid
+func (l *loader) err() error {
+	if len(l.errs) == 0 {
+		return nil
+	}
+	sort.Strings(l.errs)
+	return fmt.Errorf("invalid configuration:\n  %s", strings.Join(l.errs, "\n  "))
+}
+
+// effective returns every resolved setting ordered by key
+func (l *loader) effective() []Setting {
+	settings := make([]Setting, 0, len(l.settings))
+	for _, setting := range l.settings {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Key < settings[j].Key
+	})
+	return settings
+}
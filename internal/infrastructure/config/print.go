@@ -0,0 +1,47 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"image/pkg/logger"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Print writes the effective settings as a YAML config file, each annotated with its
+// source. Secrets are redacted, so the output is safe to share.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range c.Settings {
+		value := setting.Value
+		if setting.Secret && value != "" {
+			value = logger.Redacted
+		}
+
+		root.Content = append(root.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: setting.Key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value, Style: scalarStyle(value), LineComment: setting.Source},
+		)
+	}
+
+	if _, err := fmt.Fprintln(w, "# Effective configuration; each value is annotated with its source"); err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return fmt.Errorf("failed to print configuration: %w", err)
+	}
+	return encoder.Close()
+}
+
+// scalarStyle quotes values that would not read back as the same setting unquoted
+func scalarStyle(value string) yaml.Style {
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(value), &decoded); err != nil || decoded == nil || fmt.Sprint(decoded) != value {
+		return yaml.DoubleQuotedStyle
+	}
+	return 0
+}
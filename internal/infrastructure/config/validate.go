@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/infrastructure/tracing"
+	"image/pkg/logger"
+)
+
+// validate checks the settings that parsed but are out of range or inconsistent,
+// recording every problem in the loader
+func (l *loader) validate(cfg *Config) {
+	if len(cfg.ModelsLab.Keys) == 0 && cfg.ModelsLab.KeysFile == "" {
+		l.invalid("MODELSLAB_API_KEY", "a ModelsLab API key is required; set MODELSLAB_API_KEY, MODELSLAB_API_KEY_FILE, MODELSLAB_API_KEYS or MODELSLAB_API_KEYS_FILE")
+	}
+
+	// Without both, a token the identity provider issued for another application is accepted
+	if cfg.Auth.JWT.JWKS != "" {
+		if cfg.Auth.JWT.Issuer == "" {
+			l.invalid("AUTH_JWT_ISSUER", "is required when AUTH_JWT_JWKS is set")
+		}
+		if cfg.Auth.JWT.Audience == "" {
+			l.invalid("AUTH_JWT_AUDIENCE", "is required when AUTH_JWT_JWKS is set")
+		}
+	}
+
+	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
+		l.invalid("SERVER_PORT", "must be between 1 and 65535 but got %d", cfg.Server.Port)
+	}
+	l.positive("SERVER_READ_TIMEOUT", cfg.Server.ReadTimeout)
+	l.positive("SERVER_WRITE_TIMEOUT", cfg.Server.WriteTimeout)
+	l.positive("SERVER_DRAIN_TIMEOUT", cfg.Server.DrainTimeout)
+	l.positive("SERVER_SHUTDOWN_TIMEOUT", cfg.Server.ShutdownTimeout)
+	l.positive("SERVER_HANDLER_TIMEOUT", cfg.Server.HandlerTimeout)
+	if cfg.Server.MaxBodyBytes < 1 {
+		l.invalid("SERVER_MAX_BODY_KB", "must be at least 1 but got %d", cfg.Server.MaxBodyBytes>>10)
+	}
+	if cfg.Security.HSTSMaxAge < 0 {
+		l.invalid("SECURITY_HSTS_MAX_AGE", "must not be negative but got %s", cfg.Security.HSTSMaxAge)
+	}
+
+	if err := cfg.CORS.Validate(); err != nil {
+		l.invalid("CORS_ALLOWED_ORIGINS", "%v", err)
+	}
+	l.knownRoutes("CORS_ROUTE_METHODS", cfg.CORS.RouteMethods)
+	l.knownRoutes("CORS_ROUTE_HEADERS", cfg.CORS.RouteHeaders)
+	bodyRoutes := make([]string, 0, len(cfg.Server.RouteMaxBodyBytes))
+	for route := range cfg.Server.RouteMaxBodyBytes {
+		bodyRoutes = append(bodyRoutes, route)
+	}
+	sort.Strings(bodyRoutes)
+	for _, route := range bodyRoutes {
+		l.knownRoute("SERVER_ROUTE_MAX_BODY_KB", route)
+	}
+	timeoutRoutes := make([]string, 0, len(cfg.Server.RouteTimeouts))
+	for route := range cfg.Server.RouteTimeouts {
+		timeoutRoutes = append(timeoutRoutes, route)
+	}
+	sort.Strings(timeoutRoutes)
+	for _, route := range timeoutRoutes {
+		l.knownRoute("SERVER_ROUTE_TIMEOUTS", route)
+	}
+
+	if u, err := url.Parse(cfg.ModelsLab.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		l.invalid("MODELSLAB_BASE_URL", "expected an http or https URL but got %q", cfg.ModelsLab.BaseURL)
+	}
+	if cfg.ModelsLab.KeyStrategy != models.KeyStrategyRoundRobin && cfg.ModelsLab.KeyStrategy != models.KeyStrategyCredit {
+		l.invalid("MODELSLAB_KEY_STRATEGY", "expected %s or %s but got %q",
+			models.KeyStrategyRoundRobin, models.KeyStrategyCredit, cfg.ModelsLab.KeyStrategy)
+	}
+	l.positive("MODELSLAB_TIMEOUT", cfg.ModelsLab.Timeout)
+	l.nonNegative("MODELSLAB_MAX_RETRIES", cfg.ModelsLab.MaxRetries)
+
+	l.positive("MODELSLAB_POLL_MAX_WAIT", cfg.Polling.MaxWait)
+	// Generation routes wait for processing jobs, so they must not time out first. Batches and
+	// sweeps generate their items in rounds of BATCH_MAX_CONCURRENCY.
+	if cfg.Batch.MaxConcurrency > 0 {
+		rounds := map[string]int{
+			"text2img":  1,
+			"reproduce": 1,
+			"batch":     ceilDiv(cfg.Batch.MaxItems, cfg.Batch.MaxConcurrency),
+			"sweep":     ceilDiv(cfg.Sweep.MaxCells, cfg.Batch.MaxConcurrency),
+		}
+		var shortRoutes []string
+		for _, route := range []string{"text2img", "reproduce", "batch", "sweep"} {
+			timeout, ok := cfg.Server.RouteTimeouts[route]
+			if !ok {
+				timeout = cfg.Server.HandlerTimeout
+			}
+			if need := time.Duration(rounds[route]) * generationWait(cfg); timeout <= need {
+				shortRoutes = append(shortRoutes, fmt.Sprintf("%s=%s (needs more than %s)", route, timeout, need))
+			}
+		}
+		if len(shortRoutes) > 0 {
+			l.invalid("SERVER_ROUTE_TIMEOUTS", "generation routes must outlast the upstream retries and polling of every round but got %s",
+				strings.Join(shortRoutes, "; "))
+		}
+	}
+	l.positive("MODELSLAB_POLL_BASE_INTERVAL", cfg.Polling.BaseInterval)
+	if cfg.Polling.MaxInterval < cfg.Polling.BaseInterval {
+		l.invalid("MODELSLAB_POLL_MAX_INTERVAL", "must not be less than MODELSLAB_POLL_BASE_INTERVAL (%s) but got %s",
+			cfg.Polling.BaseInterval, cfg.Polling.MaxInterval)
+	}
+	if cfg.Polling.Multiplier < 1 {
+		l.invalid("MODELSLAB_POLL_MULTIPLIER", "must be at least 1 but got %g", cfg.Polling.Multiplier)
+	}
+	if len(cfg.Polling.Endpoints) == 0 {
+		l.invalid("MODELSLAB_POLL_ENDPOINTS", "at least one status endpoint is required")
+	}
+
+	l.atLeastOne("LIMITS_MAX_PIXELS", cfg.Limits.MaxPixels)
+	l.atLeastOne("LIMITS_MAX_SAMPLES", cfg.Limits.MaxSamples)
+	l.atLeastOne("BATCH_MAX_ITEMS", cfg.Batch.MaxItems)
+	l.atLeastOne("BATCH_MAX_CONCURRENCY", cfg.Batch.MaxConcurrency)
+	l.atLeastOne("SWEEP_MAX_CELLS", cfg.Sweep.MaxCells)
+	l.nonNegative("STORAGE_MAX_GENERATIONS", cfg.Storage.MaxGenerations)
+	l.nonNegative("QUOTA_DAILY_IMAGES", cfg.Usage.DailyImageQuota)
+	l.nonNegative("QUOTA_MONTHLY_IMAGES", cfg.Usage.MonthlyImageQuota)
+
+	if cfg.Logging.Format != logger.FormatJSON && cfg.Logging.Format != logger.FormatText {
+		l.invalid("LOG_FORMAT", "expected %s or %s but got %q", logger.FormatJSON, logger.FormatText, cfg.Logging.Format)
+	}
+
+	switch cfg.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	default:
+		l.invalid("TRACING_EXPORTER", "expected %s, %s or %s but got %q",
+			tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP, cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		l.invalid("TRACING_SAMPLE_RATIO", "must be between 0 and 1 but got %g", cfg.Tracing.SampleRatio)
+	}
+
+	l.positive("HEALTH_PROBE_TIMEOUT", cfg.Health.ProbeTimeout)
+
+	// With policies configured, a role without one is denied everything, which for a
+	// role mapped to scopes is almost certainly a misspelling
+	if len(cfg.RolePolicies) > 0 {
+		roles := make([]string, 0, len(cfg.Auth.JWT.RoleScopes))
+		for role := range cfg.Auth.JWT.RoleScopes {
+			roles = append(roles, role)
+		}
+		sort.Strings(roles)
+		for _, role := range roles {
+			if _, ok := cfg.RolePolicies[role]; !ok && !containsString(cfg.Auth.JWT.RoleScopes[role], string(models.ScopeAdmin)) {
+				l.invalid("AUTH_JWT_ROLE_SCOPES", "role %s has no policy in RBAC_POLICIES_FILE", role)
+			}
+		}
+	}
+
+	seen := make(map[string]bool, len(cfg.Models))
+	for i, model := range cfg.Models {
+		for _, problem := range model.problems() {
+			l.fail("MODELS_CATALOG_FILE", "model %d (%s): %s", i+1, model.ID, problem)
+		}
+		if seen[model.ID] {
+			l.fail("MODELS_CATALOG_FILE", "model %s is defined more than once", model.ID)
+		}
+		seen[model.ID] = true
+	}
+}
+
+// knownRoutes records a problem for every key of a per-route setting that names no API
+// route, since a misspelled route silently falls back to the defaults
+func (l *loader) knownRoutes(key string, routes map[string][]string) {
+	names := make([]string, 0, len(routes))
+	for route := range routes {
+		names = append(names, route)
+	}
+	sort.Strings(names)
+	for _, route := range names {
+		l.knownRoute(key, route)
+	}
+}
+
+// knownRoute records a problem when a per-route setting names no API route
+func (l *loader) knownRoute(key, route string) {
+	if !models.IsAPIRoute(route) {
+		l.fail(key, "unknown route %s; expected one of %s", route, strings.Join(models.APIRoutes, ", "))
+	}
+}
+
+// positive records a problem when a duration is not greater than zero
+func (l *loader) positive(key string, d time.Duration) {
+	if d <= 0 {
+		l.invalid(key, "must be greater than zero but got %s", d)
+	}
+}
+
+// atLeastOne records a problem when a count is below one
+func (l *loader) atLeastOne(key string, n int) {
+	if n < 1 {
+		l.invalid(key, "must be at least 1 but got %d", n)
+	}
+}
+
+// nonNegative records a problem when a count is negative
+func (l *loader) nonNegative(key string, n int) {
+	if n < 0 {
+		l.invalid(key, "must not be negative but got %d", n)
+	}
+}
+
+// problems describes what is wrong with a model definition
+func (m ModelDefinition) problems() []string {
+	var problems []string
+	if strings.TrimSpace(m.ID) == "" {
+		problems = append(problems, "id is required")
+	}
+	if m.MaxWidth < 1 || m.MaxHeight < 1 || m.MaxSamples < 1 {
+		problems = append(problems, "max_width, max_height and max_samples must be at least 1")
+	}
+	if m.MinInferenceSteps < 1 || m.MaxInferenceSteps < m.MinInferenceSteps {
+		problems = append(problems, fmt.Sprintf("inference steps must satisfy 1 <= min (%d) <= max (%d)", m.MinInferenceSteps, m.MaxInferenceSteps))
+	}
+	if m.MinGuidanceScale < 0 || m.MaxGuidanceScale < m.MinGuidanceScale {
+		problems = append(problems, fmt.Sprintf("guidance scale must satisfy 0 <= min (%g) <= max (%g)", m.MinGuidanceScale, m.MaxGuidanceScale))
+	}
+	return problems
+}
+
+// containsString reports whether values holds value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// generationWait returns the longest a single generation may wait on the upstream: every
+// POST attempt timing out, with the client's backoff of one more second per attempt between
+// them, then polling for MODELSLAB_POLL_MAX_WAIT
+func generationWait(cfg *Config) time.Duration {
+	attempts := cfg.ModelsLab.MaxRetries
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	wait := time.Duration(attempts)*cfg.ModelsLab.Timeout + cfg.Polling.MaxWait
+	for attempt := 1; attempt < attempts; attempt++ {
+		wait += time.Duration(attempt) * time.Second
+	}
+	return wait
+}
+
+// ceilDiv divides n by d, rounding up
+func ceilDiv(n, d int) int {
+	return (n + d - 1) / d
+}
@@ -0,0 +1,220 @@
+package cors
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the cross-origin policy for browser clients. Route keys are the handler
+// names registered with the server (e.g. text2img, apikeys); their methods and headers
+// replace the defaults for that route.
+type Config struct {
+	// AllowedOrigins lists exact origins, "*", or patterns such as https://*.example.com
+	// whose wildcard stands for a single leading subdomain label
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight response
+	MaxAge       time.Duration
+	RouteMethods map[string][]string
+	RouteHeaders map[string][]string
+}
+
+// Validate reports the first problem with the configuration
+func (c Config) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("the * origin cannot be combined with credentials; list the allowed origins instead")
+			}
+			continue
+		}
+		if strings.Contains(origin, "*") {
+			if _, _, ok := splitPattern(origin); !ok {
+				return fmt.Errorf("invalid origin pattern %q; only a leading subdomain label may be a wildcard, as in https://*.example.com", origin)
+			}
+			origin = strings.Replace(origin, "*", "x", 1)
+		}
+		u, err := url.Parse(origin)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("expected origins such as https://app.example.com but got %q", origin)
+		}
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("max age must not be negative")
+	}
+	return nil
+}
+
+// splitPattern splits an origin pattern such as https://*.example.com into the parts
+// before and after its wildcard. The wildcard must be the whole first label of the host,
+// followed by a domain of at least two labels, so no pattern can match every host.
+func splitPattern(pattern string) (string, string, bool) {
+	prefix, suffix, ok := strings.Cut(pattern, "*")
+	if !ok || strings.Contains(suffix, "*") {
+		return "", "", false
+	}
+	scheme, rest, ok := strings.Cut(prefix, "://")
+	if !ok || scheme == "" || strings.ContainsAny(scheme, "/:") || rest != "" {
+		return "", "", false
+	}
+
+	domain := strings.TrimPrefix(suffix, ".")
+	if domain == suffix {
+		return "", "", false
+	}
+	host, _, _ := strings.Cut(domain, ":")
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 || strings.ContainsAny(domain, "/?#@") {
+		return "", "", false
+	}
+	for _, label := range labels {
+		if label == "" {
+			return "", "", false
+		}
+	}
+
+	return strings.ToLower(prefix), strings.ToLower(suffix), true
+}
+
+// Rule holds the methods and headers a route accepts from cross-origin callers
+type Rule struct {
+	Methods []string
+	Headers []string
+}
+
+// AllowsMethod reports whether the rule accepts the method
+func (r Rule) AllowsMethod(method string) bool {
+	for _, allowed := range r.Methods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsHeaders reports whether the rule accepts every header of a comma-separated list,
+// as sent in Access-Control-Request-Headers. A "*" header accepts any.
+func (r Rule) AllowsHeaders(list string) bool {
+	for _, header := range strings.Split(list, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		allowed := false
+		for _, candidate := range r.Headers {
+			if candidate == "*" || strings.EqualFold(candidate, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// Policy decides which cross-origin requests are allowed and the headers that say so
+type Policy struct {
+	origins     []string
+	credentials bool
+	exposed     string
+	maxAge      string
+	defaults    Rule
+	routes      map[string]Rule
+}
+
+// NewPolicy creates a policy from the configuration
+func NewPolicy(cfg Config) *Policy {
+	p := &Policy{
+		origins:     cfg.AllowedOrigins,
+		credentials: cfg.AllowCredentials,
+		exposed:     strings.Join(cfg.ExposedHeaders, ", "),
+		maxAge:      strconv.Itoa(int(cfg.MaxAge.Seconds())),
+		defaults:    Rule{Methods: cfg.AllowedMethods, Headers: cfg.AllowedHeaders},
+		routes:      make(map[string]Rule),
+	}
+
+	for route, methods := range cfg.RouteMethods {
+		rule := p.Rule(route)
+		rule.Methods = methods
+		p.routes[route] = rule
+	}
+	for route, headers := range cfg.RouteHeaders {
+		rule := p.Rule(route)
+		rule.Headers = headers
+		p.routes[route] = rule
+	}
+
+	return p
+}
+
+// AllowsOrigin reports whether a browser at origin may call the API
+func (p *Policy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, allowed := range p.origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		if matchesPattern(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPattern reports whether origin matches a pattern such as https://*.example.com,
+// whose wildcard matches exactly one subdomain label
+func matchesPattern(pattern, origin string) bool {
+	prefix, suffix, ok := splitPattern(pattern)
+	if !ok {
+		return false
+	}
+	origin = strings.ToLower(origin)
+	if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+		return false
+	}
+	label := origin[len(prefix) : len(origin)-len(suffix)]
+	return !strings.ContainsAny(label, ".:/@?#")
+}
+
+// Rule returns the methods and headers accepted on the named route
+func (p *Policy) Rule(route string) Rule {
+	if rule, ok := p.routes[route]; ok {
+		return rule
+	}
+	return p.defaults
+}
+
+// SetResponseHeaders marks a response to an allowed origin as readable by it
+func (p *Policy) SetResponseHeaders(h http.Header, origin string) {
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if p.exposed != "" {
+		h.Set("Access-Control-Expose-Headers", p.exposed)
+	}
+}
+
+// SetPreflightHeaders answers an allowed preflight with what the route accepts
+func (p *Policy) SetPreflightHeaders(h http.Header, origin string, rule Rule) {
+	h.Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(rule.Methods, ", "))
+	if len(rule.Headers) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(rule.Headers, ", "))
+	}
+	h.Set("Access-Control-Max-Age", p.maxAge)
+}
@@ -0,0 +1,129 @@
+package cors
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPolicyAllowsOrigin(t *testing.T) {
+	policy := NewPolicy(Config{
+		AllowedOrigins: []string{
+			"https://app.example.org",
+			"https://*.example.com",
+			"https://*.staging.example.net:8443",
+		},
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://app.example.org", want: true},
+		{origin: "HTTPS://APP.EXAMPLE.ORG", want: true},
+		{origin: "http://app.example.org", want: false},
+		{origin: "https://app.example.org:443", want: false},
+		{origin: "https://app.example.org.evil.com", want: false},
+		{origin: "https://app.example.com", want: true},
+		{origin: "https://App.Example.com", want: true},
+		{origin: "https://a.b.example.com", want: false},
+		{origin: "https://example.com", want: false},
+		{origin: "https://.example.com", want: false},
+		{origin: "https://evilexample.com", want: false},
+		{origin: "https://app.example.com.evil.com", want: false},
+		{origin: "https://app.example.com:8443", want: false},
+		{origin: "https://evil.com#.example.com", want: false},
+		{origin: "https://user@evil.com/.example.com", want: false},
+		{origin: "http://app.example.com", want: false},
+		{origin: "https://ci.staging.example.net:8443", want: true},
+		{origin: "https://ci.staging.example.net", want: false},
+		{origin: "null", want: false},
+		{origin: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := policy.AllowsOrigin(tt.origin); got != tt.want {
+				t.Errorf("AllowsOrigin(%q) = %v, want %v", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAllowsAnyOrigin(t *testing.T) {
+	policy := NewPolicy(Config{AllowedOrigins: []string{"*"}})
+
+	if !policy.AllowsOrigin("https://anything.example") {
+		t.Error("expected * to allow any origin")
+	}
+	if policy.AllowsOrigin("") {
+		t.Error("expected requests without an origin not to be treated as cross-origin")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "exact origin", config: Config{AllowedOrigins: []string{"https://app.example.com"}}},
+		{name: "subdomain pattern", config: Config{AllowedOrigins: []string{"https://*.example.com"}}},
+		{name: "subdomain pattern with port", config: Config{AllowedOrigins: []string{"http://*.example.com:8080"}}},
+		{name: "any origin", config: Config{AllowedOrigins: []string{"*"}}},
+		{name: "any origin with credentials", config: Config{AllowedOrigins: []string{"*"}, AllowCredentials: true}, wantErr: true},
+		{name: "bare wildcard host", config: Config{AllowedOrigins: []string{"https://*"}}, wantErr: true},
+		{name: "wildcard scheme", config: Config{AllowedOrigins: []string{"*://*"}}, wantErr: true},
+		{name: "wildcard over a top-level domain", config: Config{AllowedOrigins: []string{"https://*.com"}}, wantErr: true},
+		{name: "wildcard inside a label", config: Config{AllowedOrigins: []string{"https://*example.com"}}, wantErr: true},
+		{name: "wildcard in the middle", config: Config{AllowedOrigins: []string{"https://app.*.example.com"}}, wantErr: true},
+		{name: "two wildcards", config: Config{AllowedOrigins: []string{"https://*.*.example.com"}}, wantErr: true},
+		{name: "pattern with a path", config: Config{AllowedOrigins: []string{"https://*.example.com/app"}}, wantErr: true},
+		{name: "missing scheme", config: Config{AllowedOrigins: []string{"app.example.com"}}, wantErr: true},
+		{name: "origin with a path", config: Config{AllowedOrigins: []string{"https://app.example.com/"}}, wantErr: true},
+		{name: "negative max age", config: Config{MaxAge: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr && err == nil {
+				t.Fatal("expected an error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("expected the config to be valid, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPolicyRouteRules(t *testing.T) {
+	policy := NewPolicy(Config{
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		RouteMethods:   map[string][]string{"apikeys": {"GET"}},
+		RouteHeaders:   map[string][]string{"text2img": {"*"}},
+	})
+
+	tests := []struct {
+		route   string
+		method  string
+		headers string
+		want    bool
+	}{
+		{route: "models", method: "post", headers: "content-type, x-api-key", want: true},
+		{route: "models", method: "DELETE", want: false},
+		{route: "models", method: "GET", headers: "X-Debug", want: false},
+		{route: "apikeys", method: "POST", want: false},
+		{route: "apikeys", method: "GET", headers: "X-API-Key", want: true},
+		{route: "text2img", method: "POST", headers: "X-Debug, X-Trace", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.route+" "+tt.method, func(t *testing.T) {
+			rule := policy.Rule(tt.route)
+			if got := rule.AllowsMethod(tt.method) && rule.AllowsHeaders(tt.headers); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
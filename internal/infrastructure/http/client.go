@@ -7,19 +7,41 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"image/internal/domain/models"
 	"image/internal/domain/ports"
+	"image/internal/infrastructure/tracing"
 	apperrors "image/pkg/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// maxLoggedBody is how much of a response body is logged or kept in errors
+const maxLoggedBody = 512
+
+// tracer creates a span for every attempt of an upstream request
+var tracer = otel.Tracer("image/internal/infrastructure/http")
+
+// apiKeySetter is implemented by request bodies that carry the ModelsLab API key
+type apiKeySetter interface {
+	SetAPIKey(key string)
+}
+
 // Client implements the HTTPClient interface
 type Client struct {
 	client     *http.Client
 	baseURL    string
 	apiKey     string
+	keys       ports.UpstreamKeyPool
 	maxRetries int
+	metrics    ports.Metrics
 	logger     ports.Logger
 }
 
@@ -59,6 +81,21 @@ func WithMaxRetries(maxRetries int) ClientOption {
 	}
 }
 
+// WithMetrics records the latency, status and retries of every upstream request
+func WithMetrics(metrics ports.Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// WithKeyPool draws the API key of every request from the pool instead of the fixed key,
+// moving on to the next key when the upstream rejects one
+func WithKeyPool(keys ports.UpstreamKeyPool) ClientOption {
+	return func(c *Client) {
+		c.keys = keys
+	}
+}
+
 // Do executes an HTTP request with retries and error handling
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	var resp *http.Response
@@ -66,13 +103,15 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	var attempt int
 
 	for attempt = 1; attempt <= c.maxRetries; attempt++ {
-		c.logger.Debug("Attempting request",
+		c.logger.DebugContext(req.Context(), "Attempting request",
 			"attempt", attempt,
 			"url", req.URL.String(),
 			"method", req.Method,
 		)
 
-		resp, err = c.client.Do(req)
+		start := time.Now()
+		resp, err = c.attempt(req, attempt)
+		c.observe(req, resp, time.Since(start), attempt > 1)
 		if err == nil && resp.StatusCode < 500 {
 			return resp, nil
 		}
@@ -97,6 +136,63 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	return resp, nil
 }
 
+// attempt sends the request once within a client span, propagating the trace upstream
+func (c *Client) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, span := tracer.Start(req.Context(), "http.Client.Do",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.path", endpointLabel(req.URL.String(), c.baseURL)),
+			attribute.Int("attempt", attempt),
+		),
+	)
+	defer span.End()
+
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= 500 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// observe records an upstream request attempt in the metrics
+func (c *Client) observe(req *http.Request, resp *http.Response, duration time.Duration, retry bool) {
+	if c.metrics == nil {
+		return
+	}
+
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+	c.metrics.ObserveUpstream(endpointLabel(req.URL.String(), c.baseURL), status, duration, retry)
+}
+
+// endpointLabel returns the path of a request relative to the base URL, with numeric path
+// segments such as job IDs replaced by {id} to keep the label's cardinality bounded
+func endpointLabel(url, baseURL string) string {
+	path := strings.TrimPrefix(url, baseURL)
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if _, err := strconv.ParseInt(segment, 10, 64); err == nil {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
 // Get sends a GET request
 func (c *Client) Get(ctx context.Context, path string, response interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
@@ -109,7 +205,7 @@ func (c *Client) Get(ctx context.Context, path string, response interface{}) err
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 
 	// Log request details
-	c.logger.Debug("Preparing request",
+	c.logger.DebugContext(ctx, "Preparing request",
 		"url", req.URL.String(),
 		"method", "GET",
 	)
@@ -133,7 +229,7 @@ func (c *Client) Get(ctx context.Context, path string, response interface{}) err
 
 	// Handle empty response body
 	if len(bodyBytes) == 0 {
-		c.logger.Debug("Empty response body received",
+		c.logger.DebugContext(ctx, "Empty response body received",
 			"status_code", resp.StatusCode,
 			"url", req.URL.String(),
 		)
@@ -142,8 +238,8 @@ func (c *Client) Get(ctx context.Context, path string, response interface{}) err
 
 	// Decode response
 	if err := json.Unmarshal(bodyBytes, response); err != nil {
-		c.logger.Debug("Failed to decode response",
-			"body", string(bodyBytes),
+		c.logger.DebugContext(ctx, "Failed to decode response",
+			"body", truncateBody(bodyBytes),
 			"error", err,
 		)
 		return apperrors.NewInternalServerError("Failed to decode response", err)
@@ -154,14 +250,67 @@ func (c *Client) Get(ctx context.Context, path string, response interface{}) err
 
 // Post sends a POST request with JSON body
 func (c *Client) Post(ctx context.Context, path string, body interface{}, response interface{}) error {
-	// Set API key in request body for ModelsLab API
-	if bodyStruct, ok := body.(*models.ModelsLabAPIRequest); ok {
-		bodyStruct.Key = c.apiKey
-		c.logger.Debug("Setting API key for request",
-			"key_length", len(c.apiKey),
+	bodyStruct, carriesKey := body.(apiKeySetter)
+	if !carriesKey || c.keys == nil {
+		// Set API key in request body for ModelsLab API
+		if carriesKey {
+			c.setAPIKey(bodyStruct, c.apiKey, path)
+		}
+		_, _, err := c.post(ctx, path, body, response)
+		return err
+	}
+
+	// Requests about an existing job must use the key that created it
+	if name := models.UpstreamKeyFromContext(ctx); name != "" {
+		key, err := c.keys.Named(name)
+		if err != nil {
+			return err
+		}
+		c.setAPIKey(bodyStruct, key, path)
+		status, retryAfter, err := c.post(ctx, path, body, response)
+		c.keys.Report(key, status, retryAfter)
+		return err
+	}
+
+	// Every rejection takes a key out of rotation, so this ends once the pool runs dry
+	var lastErr error
+	for {
+		key, err := c.keys.Acquire()
+		if err != nil {
+			if lastErr != nil {
+				return lastErr
+			}
+			return err
+		}
+
+		c.setAPIKey(bodyStruct, key, path)
+		status, retryAfter, err := c.post(ctx, path, body, response)
+		c.keys.Report(key, status, retryAfter)
+
+		if status != http.StatusUnauthorized && status != http.StatusTooManyRequests {
+			return err
+		}
+
+		c.logger.InfoContext(ctx, "ModelsLab rejected API key, retrying with the next key",
+			"status_code", status,
 			"path", path,
 		)
+		lastErr = err
 	}
+}
+
+// setAPIKey sets the API key in a request body
+func (c *Client) setAPIKey(body apiKeySetter, key, path string) {
+	body.SetAPIKey(key)
+	c.logger.Debug("Setting API key for request",
+		"key_length", len(key),
+		"path", path,
+	)
+}
+
+// post sends the POST request and returns the response status and any Retry-After delay.
+// The status is zero when no response was received.
+func (c *Client) post(ctx context.Context, path string, body interface{}, response interface{}) (int, time.Duration, error) {
 
 	// Add additional headers for Cloudflare
 	headers := map[string]string{
@@ -176,19 +325,19 @@ func (c *Client) Post(ctx context.Context, path string, body interface{}, respon
 	}
 
 	// Log request details
-	c.logger.Debug("Preparing request",
+	c.logger.DebugContext(ctx, "Preparing request",
 		"url", c.baseURL+path,
 		"method", "POST",
 	)
 
 	jsonBody, err := json.Marshal(body)
 	if err != nil {
-		return apperrors.NewInvalidRequestError("Failed to marshal request body", err)
+		return 0, 0, apperrors.NewInvalidRequestError("Failed to marshal request body", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+path, bytes.NewBuffer(jsonBody))
 	if err != nil {
-		return apperrors.NewInternalServerError("Failed to create request", err)
+		return 0, 0, apperrors.NewInternalServerError("Failed to create request", err)
 	}
 
 	// Set headers
@@ -196,48 +345,94 @@ func (c *Client) Post(ctx context.Context, path string, body interface{}, respon
 		req.Header.Set(key, value)
 	}
 
-	// Log request body for debugging
-	c.logger.Debug("Request payload",
-		"body", string(jsonBody),
+	// The payload carries the API key, so only its size is logged
+	c.logger.DebugContext(ctx, "Request payload",
+		"body_bytes", len(jsonBody),
 		"url", req.URL.String(),
 	)
 
 	resp, err := c.Do(req)
 	if err != nil {
-		return err
+		return 0, 0, err
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return apperrors.NewInternalServerError("Failed to read response body", err)
+		return resp.StatusCode, 0, apperrors.NewInternalServerError("Failed to read response body", err)
 	}
 
 	// Handle error status codes
 	if resp.StatusCode >= 400 {
-		return c.handleErrorResponse(resp.StatusCode, bodyBytes)
+		return resp.StatusCode, retryAfter(resp), c.handleErrorResponse(resp.StatusCode, bodyBytes)
 	}
 
 	// Handle empty response body
 	if len(bodyBytes) == 0 {
-		c.logger.Debug("Empty response body received",
+		c.logger.DebugContext(ctx, "Empty response body received",
 			"status_code", resp.StatusCode,
 			"url", req.URL.String(),
 		)
-		return nil
+		return resp.StatusCode, 0, nil
+	}
+
+	// ModelsLab rejects keys with an error body rather than an error status
+	if status := keyRejection(bodyBytes); status != 0 {
+		return status, 0, c.handleErrorResponse(status, bodyBytes)
 	}
 
 	// Decode response
 	if err := json.Unmarshal(bodyBytes, response); err != nil {
-		c.logger.Debug("Failed to decode response",
-			"body", string(bodyBytes),
+		c.logger.DebugContext(ctx, "Failed to decode response",
+			"body", truncateBody(bodyBytes),
 			"error", err,
 		)
-		return apperrors.NewInternalServerError("Failed to decode response", err)
+		return resp.StatusCode, 0, apperrors.NewInternalServerError("Failed to decode response", err)
 	}
 
-	return nil
+	return resp.StatusCode, 0, nil
+}
+
+// keyRejection returns the HTTP status matching an error body that rejects the request's API
+// key, 401 for an invalid key and 429 for a rate-limited one, or zero for any other body
+func keyRejection(body []byte) int {
+	var errorResp struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errorResp); err != nil || errorResp.Status != "error" {
+		return 0
+	}
+
+	message := strings.ToLower(errorResp.Message)
+	switch {
+	case strings.Contains(message, "api key") &&
+		(strings.Contains(message, "invalid") || strings.Contains(message, "not found") ||
+			strings.Contains(message, "not valid") || strings.Contains(message, "expired")):
+		return http.StatusUnauthorized
+	case strings.Contains(message, "rate limit") || strings.Contains(message, "too many requests"):
+		return http.StatusTooManyRequests
+	default:
+		return 0
+	}
+}
+
+// truncateBody returns a response body for logging, cut off after maxLoggedBody bytes
+func truncateBody(body []byte) string {
+	if len(body) <= maxLoggedBody {
+		return string(body)
+	}
+	return fmt.Sprintf("%s… (%d bytes)", body[:maxLoggedBody], len(body))
+}
+
+// retryAfter returns the delay requested by a Retry-After header in seconds, or zero
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // handleErrorResponse processes error responses from the API
@@ -245,7 +440,7 @@ func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
 	// Log raw error response for debugging
 	c.logger.Debug("Received error response",
 		"status_code", statusCode,
-		"body", string(body),
+		"body", truncateBody(body),
 	)
 
 	// Try to parse as ModelsLab API error
@@ -266,7 +461,7 @@ func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
 
 		return apperrors.NewExternalAPIError(
 			fmt.Sprintf("API error with status code %d", statusCode),
-			fmt.Errorf("raw response: %s", string(body)),
+			fmt.Errorf("raw response: %s", truncateBody(body)),
 		)
 	}
 
@@ -0,0 +1,114 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"image/internal/domain/models"
+)
+
+func TestKeyRejection(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "invalid key", body: `{"status":"error","message":"Invalid Api Key"}`, want: http.StatusUnauthorized},
+		{name: "expired key", body: `{"status":"error","message":"API key has expired"}`, want: http.StatusUnauthorized},
+		{name: "unknown key", body: `{"status":"error","message":"api key not found"}`, want: http.StatusUnauthorized},
+		{name: "rate limited", body: `{"status":"error","message":"Rate limit exceeded, slow down"}`, want: http.StatusTooManyRequests},
+		{name: "too many requests", body: `{"status":"error","message":"Too Many Requests"}`, want: http.StatusTooManyRequests},
+		{name: "other error", body: `{"status":"error","message":"Prompt is required"}`, want: 0},
+		{name: "success", body: `{"status":"success","message":"Invalid Api Key"}`, want: 0},
+		{name: "not JSON", body: `Invalid Api Key`, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keyRejection([]byte(tt.body)); got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingLogger keeps every message and field it is given, before any redaction
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *recordingLogger) record(msg string, err error, fields ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprint(msg, err, fields))
+}
+
+func (l *recordingLogger) Info(msg string, fields ...interface{})  { l.record(msg, nil, fields...) }
+func (l *recordingLogger) Debug(msg string, fields ...interface{}) { l.record(msg, nil, fields...) }
+func (l *recordingLogger) Error(msg string, err error, fields ...interface{}) {
+	l.record(msg, err, fields...)
+}
+func (l *recordingLogger) InfoContext(ctx context.Context, msg string, fields ...interface{}) {
+	l.record(msg, nil, fields...)
+}
+func (l *recordingLogger) DebugContext(ctx context.Context, msg string, fields ...interface{}) {
+	l.record(msg, nil, fields...)
+}
+func (l *recordingLogger) ErrorContext(ctx context.Context, msg string, err error, fields ...interface{}) {
+	l.record(msg, err, fields...)
+}
+
+func TestPostNeverLogsAPIKey(t *testing.T) {
+	const apiKey = "sk-live-secret"
+
+	for _, tt := range []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "success", status: http.StatusOK, body: `{"status":"success"}`},
+		{name: "error status", status: http.StatusBadRequest, body: `{"status":"error","message":"bad request"}`},
+		{name: "undecodable body", status: http.StatusOK, body: strings.Repeat("x", 4*maxLoggedBody)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			log := &recordingLogger{}
+			client := NewClient(server.URL, apiKey, log, WithMaxRetries(1))
+
+			var response models.Text2ImgResponse
+			client.Post(context.Background(), "/images/text2img", &models.ModelsLabAPIRequest{Prompt: "a cat"}, &response)
+
+			for _, entry := range log.entries {
+				if strings.Contains(entry, apiKey) {
+					t.Errorf("API key reached the logger: %s", entry)
+				}
+				if len(entry) > 2*maxLoggedBody {
+					t.Errorf("response body was logged in full: %d bytes", len(entry))
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateBody(t *testing.T) {
+	if got := truncateBody([]byte("short")); got != "short" {
+		t.Errorf("got %q, want the whole body", got)
+	}
+
+	long := truncateBody([]byte(strings.Repeat("x", maxLoggedBody+100)))
+	if !strings.HasPrefix(long, strings.Repeat("x", maxLoggedBody)+"…") || !strings.HasSuffix(long, fmt.Sprintf("(%d bytes)", maxLoggedBody+100)) {
+		t.Errorf("got %q, want the first %d bytes and the full size", long, maxLoggedBody)
+	}
+}
@@ -0,0 +1,316 @@
+package keypool
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// prefixLength is how much of a secret is shown when reporting key status
+const prefixLength = 6
+
+// Source loads the keys of the pool; it is called again on every reload
+type Source func() ([]models.UpstreamKey, error)
+
+// entry is a pooled key and its health
+type entry struct {
+	key models.UpstreamKey
+	// disabled is set when the upstream rejected the key as invalid; only a reload clears it
+	disabled      bool
+	cooldownUntil time.Time
+	remaining     float64
+	requests      int64
+	rejections    int64
+}
+
+// healthy reports whether the key can be used at now
+func (e *entry) healthy(now time.Time) bool {
+	if e.disabled || now.Before(e.cooldownUntil) {
+		return false
+	}
+	return e.key.Credit == 0 || e.remaining > 0
+}
+
+// Pool implements the UpstreamKeyPool interface. Keys the upstream rejects as invalid are
+// taken out of rotation until the next reload; rate-limited keys cool down and come back.
+type Pool struct {
+	source   Source
+	strategy string
+	cooldown time.Duration
+	logger   ports.Logger
+
+	entries []*entry
+	next    int
+	mu      sync.Mutex
+}
+
+// NewPool creates a new key pool and loads its keys from source
+func NewPool(source Source, strategy string, cooldown time.Duration, logger ports.Logger) (*Pool, error) {
+	if strategy != models.KeyStrategyRoundRobin && strategy != models.KeyStrategyCredit {
+		return nil, fmt.Errorf("unknown key strategy %q", strategy)
+	}
+
+	p := &Pool{
+		source:   source,
+		strategy: strategy,
+		cooldown: cooldown,
+		logger:   logger,
+	}
+
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Strategy returns how the pool picks keys
+func (p *Pool) Strategy() string {
+	return p.strategy
+}
+
+// Acquire picks the key for the next upstream request
+func (p *Pool) Acquire() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var picked *entry
+
+	switch p.strategy {
+	case models.KeyStrategyCredit:
+		// Prefer the largest remaining budget; untracked keys are used once budgets run out
+		for _, e := range p.entries {
+			if !e.healthy(now) {
+				continue
+			}
+			if picked == nil || creditRank(e) > creditRank(picked) {
+				picked = e
+			}
+		}
+	default:
+		for i := range p.entries {
+			e := p.entries[(p.next+i)%len(p.entries)]
+			if e.healthy(now) {
+				picked = e
+				p.next = (p.next + i + 1) % len(p.entries)
+				break
+			}
+		}
+	}
+
+	if picked == nil {
+		return "", p.exhausted(now)
+	}
+
+	picked.requests++
+	return picked.key.Key.Reveal(), nil
+}
+
+// Named returns the key with the given name, whatever its health, for requests about a
+// job that only the key that created it can see
+func (p *Pool) Named(name string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.entries {
+		if e.key.Name == name {
+			e.requests++
+			return e.key.Key.Reveal(), nil
+		}
+	}
+
+	return "", apperrors.NewExternalAPIError(fmt.Sprintf("ModelsLab API key %s is no longer configured", name), nil)
+}
+
+// Name returns the name of a key, or an empty string when it is not in the pool
+func (p *Pool) Name(secret string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e := p.find(secret); e != nil {
+		return e.key.Name
+	}
+	return ""
+}
+
+// Report records the upstream status of a request made with the key, taking the key out of
+// rotation on 401 and cooling it down on 429. A status of zero means no response was received.
+func (p *Pool) Report(secret string, status int, retryAfter time.Duration) {
+	if status != http.StatusUnauthorized && status != http.StatusTooManyRequests {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e := p.find(secret)
+	if e == nil {
+		return
+	}
+	e.rejections++
+
+	if status == http.StatusUnauthorized {
+		e.disabled = true
+		p.logger.Error("ModelsLab rejected API key, removing it from rotation until reload",
+			errors.New("unauthorized"),
+			"key_name", e.key.Name,
+		)
+		return
+	}
+
+	if retryAfter <= 0 {
+		retryAfter = p.cooldown
+	}
+	e.cooldownUntil = time.Now().Add(retryAfter)
+	p.logger.Info("ModelsLab rate limited API key, cooling it down",
+		"key_name", e.key.Name,
+		"cooldown", retryAfter,
+	)
+}
+
+// Charge deducts credits spent with the key from its budget
+func (p *Pool) Charge(secret string, credits float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e := p.find(secret); e != nil && e.key.Credit > 0 {
+		e.remaining -= credits
+	}
+}
+
+// Reload replaces the keys with a fresh load from the source. Keys whose secret and credit
+// are unchanged keep their spent credit, cooldown and counters; new or changed keys start
+// afresh. Rejected keys are put back into rotation either way.
+func (p *Pool) Reload() error {
+	keys, err := p.source()
+	if err != nil {
+		return fmt.Errorf("failed to load ModelsLab API keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return errors.New("no ModelsLab API keys configured")
+	}
+
+	for i, key := range keys {
+		if key.Key.IsEmpty() {
+			return fmt.Errorf("ModelsLab API key %d is empty", i+1)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	entries := make([]*entry, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	kept := 0
+	for i, key := range keys {
+		if seen[key.Key.Reveal()] {
+			continue
+		}
+		seen[key.Key.Reveal()] = true
+		if key.Name == "" {
+			key.Name = fmt.Sprintf("key-%d", i+1)
+		}
+
+		e := &entry{key: key, remaining: key.Credit}
+		if previous := p.find(key.Key.Reveal()); previous != nil && previous.key.Credit == key.Credit {
+			*e = *previous
+			e.key.Name = key.Name
+			e.disabled = false
+			kept++
+		}
+		entries = append(entries, e)
+	}
+
+	p.entries = entries
+	p.next = 0
+	p.logger.Info("Loaded ModelsLab API keys", "count", len(entries), "unchanged", kept, "strategy", p.strategy)
+
+	return nil
+}
+
+// Status reports the health of every key without its secret
+func (p *Pool) Status() []models.UpstreamKeyStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]models.UpstreamKeyStatus, len(p.entries))
+	for i, e := range p.entries {
+		status := models.UpstreamKeyStatus{
+			Name:       e.key.Name,
+			Prefix:     prefix(e.key.Key.Reveal()),
+			Healthy:    e.healthy(now),
+			Requests:   e.requests,
+			Rejections: e.rejections,
+		}
+
+		switch {
+		case e.disabled:
+			status.Reason = "rejected as unauthorized"
+		case now.Before(e.cooldownUntil):
+			until := e.cooldownUntil.UTC()
+			status.Reason = "rate limited"
+			status.CooldownUntil = &until
+		case e.key.Credit > 0 && e.remaining <= 0:
+			status.Reason = "credit exhausted"
+		}
+
+		if e.key.Credit > 0 {
+			remaining := e.remaining
+			status.RemainingCredit = &remaining
+		}
+
+		statuses[i] = status
+	}
+
+	return statuses
+}
+
+// exhausted explains why no key is available; callers must hold the lock
+func (p *Pool) exhausted(now time.Time) error {
+	var soonest time.Time
+	for _, e := range p.entries {
+		if !e.disabled && now.Before(e.cooldownUntil) && (soonest.IsZero() || e.cooldownUntil.Before(soonest)) {
+			soonest = e.cooldownUntil
+		}
+	}
+
+	if !soonest.IsZero() {
+		return apperrors.NewRateLimitedError("All ModelsLab API keys are rate limited, retry later", soonest.Sub(now))
+	}
+
+	return apperrors.NewExternalAPIError("No usable ModelsLab API key available", nil)
+}
+
+// find returns the entry holding the secret; callers must hold the lock
+func (p *Pool) find(secret string) *entry {
+	for _, e := range p.entries {
+		if e.key.Key.Reveal() == secret {
+			return e
+		}
+	}
+	return nil
+}
+
+// creditRank orders keys for the credit strategy, ranking untracked keys below tracked ones
+func creditRank(e *entry) float64 {
+	if e.key.Credit == 0 {
+		return 0
+	}
+	return e.remaining
+}
+
+// prefix returns the first characters of a secret for display
+func prefix(secret string) string {
+	if len(secret) <= prefixLength {
+		return secret[:len(secret)/2] + "…"
+	}
+	return secret[:prefixLength] + "…"
+}
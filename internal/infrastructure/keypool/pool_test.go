@@ -0,0 +1,270 @@
+package keypool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"image/internal/domain/models"
+	apperrors "image/pkg/errors"
+	"image/pkg/logger"
+)
+
+// staticSource returns a source that always loads the given keys
+func staticSource(keys ...models.UpstreamKey) Source {
+	return func() ([]models.UpstreamKey, error) {
+		return keys, nil
+	}
+}
+
+func newTestPool(t *testing.T, strategy string, keys ...models.UpstreamKey) *Pool {
+	t.Helper()
+
+	pool, err := NewPool(staticSource(keys...), strategy, time.Minute, logger.New(logger.WithOutput(io.Discard)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+// acquire returns the next n keys handed out by the pool
+func acquire(t *testing.T, pool *Pool, n int) []string {
+	t.Helper()
+
+	keys := make([]string, n)
+	for i := range keys {
+		key, err := pool.Acquire()
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i+1, err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+func equalKeys(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPoolRotation(t *testing.T) {
+	tests := []struct {
+		name   string
+		report func(pool *Pool)
+		want   []string
+	}{
+		{
+			name: "healthy keys take turns",
+			want: []string{"sk-a", "sk-b", "sk-c", "sk-a", "sk-b", "sk-c"},
+		},
+		{
+			name:   "rejected key is benched",
+			report: func(pool *Pool) { pool.Report("sk-b", http.StatusUnauthorized, 0) },
+			want:   []string{"sk-a", "sk-c", "sk-a", "sk-c"},
+		},
+		{
+			name:   "rate limited key cools down",
+			report: func(pool *Pool) { pool.Report("sk-a", http.StatusTooManyRequests, time.Hour) },
+			want:   []string{"sk-b", "sk-c", "sk-b", "sk-c"},
+		},
+		{
+			name: "other statuses keep the key in rotation",
+			report: func(pool *Pool) {
+				pool.Report("sk-a", http.StatusInternalServerError, 0)
+				pool.Report("sk-b", 0, 0)
+			},
+			want: []string{"sk-a", "sk-b", "sk-c", "sk-a"},
+		},
+		{
+			name:   "unknown key is ignored",
+			report: func(pool *Pool) { pool.Report("sk-z", http.StatusUnauthorized, 0) },
+			want:   []string{"sk-a", "sk-b", "sk-c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, models.KeyStrategyRoundRobin,
+				models.UpstreamKey{Name: "a", Key: "sk-a"},
+				models.UpstreamKey{Name: "b", Key: "sk-b"},
+				models.UpstreamKey{Name: "c", Key: "sk-c"},
+			)
+			if tt.report != nil {
+				tt.report(pool)
+			}
+
+			if got := acquire(t, pool, len(tt.want)); !equalKeys(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolExhausted(t *testing.T) {
+	pool := newTestPool(t, models.KeyStrategyRoundRobin,
+		models.UpstreamKey{Name: "a", Key: "sk-a"},
+		models.UpstreamKey{Name: "b", Key: "sk-b"},
+	)
+
+	pool.Report("sk-a", http.StatusUnauthorized, 0)
+	pool.Report("sk-b", http.StatusTooManyRequests, 30*time.Second)
+
+	_, err := pool.Acquire()
+	var appErr *apperrors.AppError
+	if !errors.As(err, &appErr) || appErr.Code != apperrors.ErrRateLimited {
+		t.Fatalf("expected a rate limited error while a key cools down, got %v", err)
+	}
+	if appErr.RetryAfter <= 0 || appErr.RetryAfter > 30*time.Second {
+		t.Errorf("expected to retry when the cooldown ends, got %s", appErr.RetryAfter)
+	}
+
+	pool.Report("sk-b", http.StatusUnauthorized, 0)
+	if _, err := pool.Acquire(); !errors.As(err, &appErr) || appErr.Code != apperrors.ErrExternalAPI {
+		t.Fatalf("expected an external API error once every key is rejected, got %v", err)
+	}
+
+	// A reload puts rejected keys back into rotation, but keys still cooling down wait
+	if err := pool.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := acquire(t, pool, 2); !equalKeys(got, []string{"sk-a", "sk-a"}) {
+		t.Errorf("expected only the rejected key back after a reload, got %v", got)
+	}
+}
+
+func TestPoolReloadKeepsState(t *testing.T) {
+	keys := []models.UpstreamKey{
+		{Name: "a", Key: "sk-a", Credit: 10},
+		{Name: "b", Key: "sk-b", Credit: 10},
+	}
+	pool, err := NewPool(func() ([]models.UpstreamKey, error) { return keys, nil },
+		models.KeyStrategyCredit, time.Minute, logger.New(logger.WithOutput(io.Discard)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool.Charge("sk-a", 9)
+	pool.Charge("sk-b", 4)
+	acquire(t, pool, 1)
+
+	// b is renamed, a keeps its credit, c is added and b's budget is raised in the source
+	keys = []models.UpstreamKey{
+		{Name: "a", Key: "sk-a", Credit: 10},
+		{Name: "b2", Key: "sk-b", Credit: 20},
+		{Name: "c", Key: "sk-c", Credit: 5},
+	}
+	if err := pool.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{"a": 1, "b2": 20, "c": 5}
+	statuses := pool.Status()
+	if len(statuses) != len(want) {
+		t.Fatalf("expected %d keys, got %+v", len(want), statuses)
+	}
+	for _, status := range statuses {
+		if status.RemainingCredit == nil || *status.RemainingCredit != want[status.Name] {
+			t.Errorf("%s: got remaining credit %v, want %g", status.Name, status.RemainingCredit, want[status.Name])
+		}
+	}
+
+	// The nearly exhausted key is not preferred after the reload
+	if got := acquire(t, pool, 1)[0]; got != "sk-b" {
+		t.Errorf("expected the key with the largest budget, got %s", got)
+	}
+}
+
+func TestPoolCreditStrategy(t *testing.T) {
+	pool := newTestPool(t, models.KeyStrategyCredit,
+		models.UpstreamKey{Name: "untracked", Key: "sk-u"},
+		models.UpstreamKey{Name: "small", Key: "sk-s", Credit: 5},
+		models.UpstreamKey{Name: "large", Key: "sk-l", Credit: 10},
+	)
+
+	steps := []struct {
+		charge string
+		amount float64
+		want   string
+	}{
+		{want: "sk-l"},
+		{charge: "sk-l", amount: 6, want: "sk-s"},
+		{charge: "sk-s", amount: 5, want: "sk-l"},
+		{charge: "sk-l", amount: 4, want: "sk-u"},
+	}
+
+	for i, step := range steps {
+		if step.charge != "" {
+			pool.Charge(step.charge, step.amount)
+		}
+		if got := acquire(t, pool, 1)[0]; got != step.want {
+			t.Errorf("step %d: got %s, want %s", i+1, got, step.want)
+		}
+	}
+
+	for _, status := range pool.Status() {
+		if status.Name != "untracked" && (status.Healthy || status.Reason != "credit exhausted") {
+			t.Errorf("expected %s to be exhausted, got %+v", status.Name, status)
+		}
+	}
+}
+
+func TestPoolNamed(t *testing.T) {
+	pool := newTestPool(t, models.KeyStrategyRoundRobin,
+		models.UpstreamKey{Name: "a", Key: "sk-a"},
+		models.UpstreamKey{Key: "sk-b"},
+	)
+
+	if got := pool.Name("sk-b"); got != "key-2" {
+		t.Errorf("expected unnamed keys to be named by position, got %q", got)
+	}
+	if got := pool.Name("sk-z"); got != "" {
+		t.Errorf("expected no name for an unknown key, got %q", got)
+	}
+
+	// A benched key is still used for the jobs it created
+	pool.Report("sk-a", http.StatusUnauthorized, 0)
+	if got, err := pool.Named("a"); err != nil || got != "sk-a" {
+		t.Errorf("expected the benched key by name, got %q and %v", got, err)
+	}
+	if _, err := pool.Named("removed"); err == nil {
+		t.Error("expected an error for a key that is no longer configured")
+	}
+}
+
+func TestNewPool(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy string
+		keys     []models.UpstreamKey
+	}{
+		{name: "unknown strategy", strategy: "random", keys: []models.UpstreamKey{{Key: "sk-a"}}},
+		{name: "no keys", strategy: models.KeyStrategyRoundRobin},
+		{name: "empty key", strategy: models.KeyStrategyRoundRobin, keys: []models.UpstreamKey{{Key: "sk-a"}, {Name: "b"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPool(staticSource(tt.keys...), tt.strategy, time.Minute, logger.New(logger.WithOutput(io.Discard)))
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	pool := newTestPool(t, models.KeyStrategyRoundRobin,
+		models.UpstreamKey{Name: "a", Key: "sk-a"},
+		models.UpstreamKey{Name: "b", Key: "sk-a"},
+	)
+	if n := len(pool.Status()); n != 1 {
+		t.Errorf("expected duplicate secrets to be pooled once, got %d keys", n)
+	}
+}
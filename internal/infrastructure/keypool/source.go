@@ -0,0 +1,32 @@
+package keypool
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"image/internal/domain/models"
+)
+
+// NewSource returns a source combining fixed keys with the keys listed in a JSON file,
+// which is read again on every load. An empty path uses the fixed keys only.
+func NewSource(keys []models.UpstreamKey, path string) Source {
+	return func() ([]models.UpstreamKey, error) {
+		loaded := append([]models.UpstreamKey(nil), keys...)
+		if path == "" {
+			return loaded, nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var fileKeys []models.UpstreamKey
+		if err := json.Unmarshal(data, &fileKeys); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+		}
+
+		return append(loaded, fileKeys...), nil
+	}
+}
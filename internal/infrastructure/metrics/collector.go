@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Histogram buckets in seconds. Generations routinely take tens of seconds, so the
+// request buckets reach well past typical API latencies.
+var (
+	requestBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+	pollBuckets    = []float64{1, 2, 5, 10, 20, 30, 60, 120, 180, 300}
+)
+
+// Collector implements the Metrics interface, recording the server's operational metrics
+type Collector struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	upstreamRequests *prometheus.CounterVec
+	upstreamDuration *prometheus.HistogramVec
+	upstreamRetries  *prometheus.CounterVec
+	upstreamErrors   *prometheus.CounterVec
+
+	pollAttempts *prometheus.CounterVec
+	pollDuration *prometheus.HistogramVec
+
+	inFlight *prometheus.GaugeVec
+}
+
+// NewCollector creates a collector with all metrics registered
+func NewCollector() *Collector {
+	c := &Collector{
+		registry: prometheus.NewRegistry(),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests served, by route, method and status code.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time taken to serve HTTP requests, by route, method and status code.",
+			Buckets: requestBuckets,
+		}, []string{"route", "method", "status"}),
+
+		upstreamRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "modelslab_requests_total",
+			Help: "Requests sent to ModelsLab, by endpoint and status code; status is \"error\" when no response arrived.",
+		}, []string{"endpoint", "status"}),
+		upstreamDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "modelslab_request_duration_seconds",
+			Help:    "Latency of requests sent to ModelsLab, by endpoint.",
+			Buckets: requestBuckets,
+		}, []string{"endpoint"}),
+		upstreamRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "modelslab_retries_total",
+			Help: "Requests to ModelsLab that were retries of a failed attempt, by endpoint.",
+		}, []string{"endpoint"}),
+		upstreamErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "modelslab_errors_total",
+			Help: "Requests to ModelsLab that failed without a response or with a server error, by endpoint.",
+		}, []string{"endpoint"}),
+
+		pollAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "modelslab_poll_attempts_total",
+			Help: "Status polls made while waiting for processing generations, by model.",
+		}, []string{"model"}),
+		pollDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "modelslab_poll_duration_seconds",
+			Help:    "Time spent polling processing generations until they finished, by model and outcome.",
+			Buckets: pollBuckets,
+		}, []string{"model", "outcome"}),
+
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "generations_in_flight",
+			Help: "Generations currently in progress, by model.",
+		}, []string{"model"}),
+	}
+
+	c.registry.MustRegister(
+		c.requests, c.requestDuration,
+		c.upstreamRequests, c.upstreamDuration, c.upstreamRetries, c.upstreamErrors,
+		c.pollAttempts, c.pollDuration,
+		c.inFlight,
+	)
+	return c
+}
+
+// ObserveRequest records a served HTTP request
+func (c *Collector) ObserveRequest(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	c.requests.WithLabelValues(route, method, code).Inc()
+	c.requestDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// ObserveUpstream records an upstream call attempt; status is zero when no response arrived
+func (c *Collector) ObserveUpstream(endpoint string, status int, duration time.Duration, retry bool) {
+	code := "error"
+	if status != 0 {
+		code = strconv.Itoa(status)
+	}
+	c.upstreamRequests.WithLabelValues(endpoint, code).Inc()
+	c.upstreamDuration.WithLabelValues(endpoint).Observe(duration.Seconds())
+
+	if retry {
+		c.upstreamRetries.WithLabelValues(endpoint).Inc()
+	}
+	if status == 0 || status >= 500 {
+		c.upstreamErrors.WithLabelValues(endpoint).Inc()
+	}
+}
+
+// ObservePolling records a finished polling loop for a processing generation
+func (c *Collector) ObservePolling(modelID string, attempts int, duration time.Duration, outcome string) {
+	c.pollAttempts.WithLabelValues(modelID).Add(float64(attempts))
+	c.pollDuration.WithLabelValues(modelID, outcome).Observe(duration.Seconds())
+}
+
+// GenerationStarted counts a generation as in flight
+func (c *Collector) GenerationStarted(modelID string) {
+	c.inFlight.WithLabelValues(modelID).Inc()
+}
+
+// GenerationFinished stops counting a generation as in flight
+func (c *Collector) GenerationFinished(modelID string) {
+	c.inFlight.WithLabelValues(modelID).Dec()
+}
+
+// Handler serves every metric in the Prometheus exposition format
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
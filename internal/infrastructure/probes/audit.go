@@ -0,0 +1,35 @@
+package probes
+
+import (
+	"context"
+	"fmt"
+)
+
+// Verifier reports whether a tamper-evident log still verifies
+type Verifier interface {
+	Verify(ctx context.Context) error
+}
+
+// AuditLogProbe fails while the audit log's hash chain does not verify, so a tampered log
+// takes the server out of rotation until an operator investigates
+type AuditLogProbe struct {
+	verifier Verifier
+}
+
+// NewAuditLogProbe creates a probe that fails while verifier reports an error
+func NewAuditLogProbe(verifier Verifier) *AuditLogProbe {
+	return &AuditLogProbe{verifier: verifier}
+}
+
+// Name implements the HealthProbe interface
+func (p *AuditLogProbe) Name() string {
+	return "audit_log"
+}
+
+// Check implements the HealthProbe interface
+func (p *AuditLogProbe) Check(ctx context.Context) error {
+	if err := p.verifier.Verify(ctx); err != nil {
+		return fmt.Errorf("audit log failed verification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package probes
+
+import "syscall"
+
+// availableBytes returns the space available to unprivileged users on dir's file system
+func availableBytes(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,69 @@
+package probes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ModelsLabProbe checks that the ModelsLab API is reachable. Any response below 500 counts
+// as reachable, so the probe needs no API key and spends no credits. Results are cached so
+// frequent readiness checks do not turn into a stream of upstream requests.
+type ModelsLabProbe struct {
+	baseURL string
+	ttl     time.Duration
+	client  *http.Client
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	lastErr   error
+}
+
+// NewModelsLabProbe creates a probe of the API at baseURL that caches results for ttl
+func NewModelsLabProbe(baseURL string, ttl time.Duration) *ModelsLabProbe {
+	return &ModelsLabProbe{
+		baseURL: baseURL,
+		ttl:     ttl,
+		client:  &http.Client{},
+	}
+}
+
+// Name implements the HealthProbe interface
+func (p *ModelsLabProbe) Name() string {
+	return "modelslab"
+}
+
+// Check implements the HealthProbe interface
+func (p *ModelsLabProbe) Check(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < p.ttl {
+		return p.lastErr
+	}
+
+	p.lastErr = p.request(ctx)
+	p.checkedAt = time.Now()
+	return p.lastErr
+}
+
+// request sends a lightweight GET request to the API root
+func (p *ModelsLabProbe) request(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("ModelsLab is unreachable: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("ModelsLab responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package probes
+
+import (
+	"context"
+	"errors"
+)
+
+// Drainer reports whether the server has stopped accepting new work
+type Drainer interface {
+	Draining() bool
+}
+
+// ShutdownProbe fails once the server starts draining, so load balancers stop routing new
+// requests to it while in-flight generations finish
+type ShutdownProbe struct {
+	drainer Drainer
+}
+
+// NewShutdownProbe creates a probe that fails while drainer is draining
+func NewShutdownProbe(drainer Drainer) *ShutdownProbe {
+	return &ShutdownProbe{drainer: drainer}
+}
+
+// Name implements the HealthProbe interface
+func (p *ShutdownProbe) Name() string {
+	return "shutdown"
+}
+
+// Check implements the HealthProbe interface
+func (p *ShutdownProbe) Check(ctx context.Context) error {
+	if p.drainer.Draining() {
+		return errors.New("server is shutting down")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package probes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WritableDirProbe checks that a directory holding persisted records can be written to
+type WritableDirProbe struct {
+	name string
+	dir  string
+}
+
+// NewWritableDirProbe creates a probe named name for the directory dir
+func NewWritableDirProbe(name, dir string) *WritableDirProbe {
+	return &WritableDirProbe{
+		name: name,
+		dir:  dir,
+	}
+}
+
+// Name implements the HealthProbe interface
+func (p *WritableDirProbe) Name() string {
+	return p.name
+}
+
+// Check implements the HealthProbe interface by creating and removing a temporary file
+func (p *WritableDirProbe) Check(ctx context.Context) error {
+	if err := os.MkdirAll(p.dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", p.dir, err)
+	}
+
+	file, err := os.CreateTemp(p.dir, ".health-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", p.dir, err)
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// DiskSpaceProbe checks that the file system holding a directory has space left
+type DiskSpaceProbe struct {
+	dir     string
+	minFree uint64
+}
+
+// NewDiskSpaceProbe creates a probe requiring at least minFree bytes available to dir
+func NewDiskSpaceProbe(dir string, minFree uint64) *DiskSpaceProbe {
+	return &DiskSpaceProbe{
+		dir:     dir,
+		minFree: minFree,
+	}
+}
+
+// Name implements the HealthProbe interface
+func (p *DiskSpaceProbe) Name() string {
+	return "disk_space"
+}
+
+// Check implements the HealthProbe interface. A directory that does not exist yet is
+// measured on the file system of its nearest existing parent.
+func (p *DiskSpaceProbe) Check(ctx context.Context) error {
+	dir := p.dir
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := availableBytes(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read free space of %s: %w", p.dir, err)
+	}
+
+	if free < p.minFree {
+		return fmt.Errorf("%s has %d MB free, below the minimum of %d MB", p.dir, free>>20, p.minFree>>20)
+	}
+	return nil
+}
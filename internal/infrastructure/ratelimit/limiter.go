@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"image/internal/domain/models"
+)
+
+// idleSweepInterval is how often buckets that have refilled completely are dropped
+const idleSweepInterval = time.Minute
+
+// Rule describes a token bucket: Requests tokens are added every Period, up to Burst
+type Rule struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParseRule parses a rule such as "10/m" or "100/h:20", where the optional suffix is the burst.
+// Periods are s, m, h or a Go duration such as 30s.
+func ParseRule(value string) (Rule, error) {
+	spec, burstValue, hasBurst := strings.Cut(strings.TrimSpace(value), ":")
+
+	requestsValue, periodValue, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("expected requests/period but got %q", value)
+	}
+
+	requests, err := strconv.Atoi(requestsValue)
+	if err != nil || requests < 1 {
+		return Rule{}, fmt.Errorf("invalid request count in %q", value)
+	}
+
+	var period time.Duration
+	switch periodValue {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		if period, err = time.ParseDuration(periodValue); err != nil || period <= 0 {
+			return Rule{}, fmt.Errorf("invalid period in %q", value)
+		}
+	}
+
+	burst := requests
+	if hasBurst {
+		if burst, err = strconv.Atoi(burstValue); err != nil || burst < 1 {
+			return Rule{}, fmt.Errorf("invalid burst in %q", value)
+		}
+	}
+
+	return Rule{Requests: requests, Period: period, Burst: burst}, nil
+}
+
+// String formats the rule in the form accepted by ParseRule
+func (r Rule) String() string {
+	return fmt.Sprintf("%d/%s:%d", r.Requests, r.Period, r.Burst)
+}
+
+// Result describes the outcome of a rate limit check
+type Result struct {
+	Allowed bool
+	// Limit is the bucket capacity
+	Limit int
+	// Remaining is the number of requests that can be made right away
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, when denied
+	RetryAfter time.Duration
+}
+
+// bucket holds the tokens available to one key
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter is a token bucket rate limiter keyed by caller
+type Limiter struct {
+	rule      Rule
+	rate      float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewLimiter creates a limiter enforcing the rule for each key independently
+func NewLimiter(rule Rule) *Limiter {
+	return &Limiter{
+		rule:      rule,
+		rate:      float64(rule.Requests) / rule.Period.Seconds(),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Rule returns the rule the limiter enforces
+func (l *Limiter) Rule() Rule {
+	return l.rule
+}
+
+// Allow takes a token from the key's bucket if one is available
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	burst := float64(l.rule.Burst)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	result := Result{Limit: l.rule.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.duration(1 - b.tokens)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = l.duration(burst - b.tokens)
+
+	return result
+}
+
+// sweep drops buckets that have been idle long enough to be full; callers must hold the lock
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	refill := l.duration(float64(l.rule.Burst))
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// duration returns how long it takes to accumulate the given number of tokens
+func (l *Limiter) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// KeyFromContext identifies the caller for rate limiting: the authenticated principal within
+// its tenant when there is one, otherwise the client IP address
+func KeyFromContext(ctx context.Context) string {
+	if principal, ok := models.PrincipalFromContext(ctx); ok {
+		return "principal:" + models.TenantFromContext(ctx) + "/" + principal.Kind + ":" + principal.ID
+	}
+	return "ip:" + models.ClientIPFromContext(ctx)
+}
@@ -0,0 +1,164 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"image/internal/domain/models"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Rule
+		wantErr bool
+	}{
+		{value: "10/m", want: Rule{Requests: 10, Period: time.Minute, Burst: 10}},
+		{value: "100/h:20", want: Rule{Requests: 100, Period: time.Hour, Burst: 20}},
+		{value: " 5/s ", want: Rule{Requests: 5, Period: time.Second, Burst: 5}},
+		{value: "3/30s:1", want: Rule{Requests: 3, Period: 30 * time.Second, Burst: 1}},
+		{value: "10", wantErr: true},
+		{value: "0/m", wantErr: true},
+		{value: "x/m", wantErr: true},
+		{value: "10/fortnight", wantErr: true},
+		{value: "10/-1s", wantErr: true},
+		{value: "10/m:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRule(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if again, err := ParseRule(got.String()); err != nil || again != got {
+				t.Errorf("String() %q does not parse back to the rule", got.String())
+			}
+		})
+	}
+}
+
+func TestLimiterAllow(t *testing.T) {
+	limiter := NewLimiter(Rule{Requests: 60, Period: time.Minute, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		result := limiter.Allow("a")
+		if !result.Allowed {
+			t.Fatalf("request %d within the burst was denied", i+1)
+		}
+		if result.Limit != 3 || result.Remaining != 2-i {
+			t.Errorf("request %d: got limit %d and remaining %d", i+1, result.Limit, result.Remaining)
+		}
+	}
+
+	denied := limiter.Allow("a")
+	if denied.Allowed {
+		t.Fatal("request over the burst was allowed")
+	}
+	if denied.RetryAfter <= 0 || denied.RetryAfter > time.Second {
+		t.Errorf("expected to retry within a second at one token per second, got %s", denied.RetryAfter)
+	}
+	if denied.Reset <= 2*time.Second || denied.Reset > 3*time.Second {
+		t.Errorf("expected the bucket to refill in about 3s, got %s", denied.Reset)
+	}
+
+	if !limiter.Allow("b").Allowed {
+		t.Error("another key shares the exhausted bucket")
+	}
+}
+
+func TestLimiterRefill(t *testing.T) {
+	limiter := NewLimiter(Rule{Requests: 1, Period: time.Second, Burst: 2})
+
+	limiter.Allow("a")
+	limiter.Allow("a")
+	if limiter.Allow("a").Allowed {
+		t.Fatal("request over the burst was allowed")
+	}
+
+	// Pretend a second and a half has passed: one token is back, never more than the burst
+	limiter.mu.Lock()
+	limiter.buckets["a"].updated = time.Now().Add(-1500 * time.Millisecond)
+	limiter.mu.Unlock()
+
+	if !limiter.Allow("a").Allowed {
+		t.Fatal("refilled token was not available")
+	}
+	if limiter.Allow("a").Allowed {
+		t.Fatal("more tokens were refilled than time allows")
+	}
+
+	limiter.mu.Lock()
+	limiter.buckets["a"].updated = time.Now().Add(-time.Hour)
+	limiter.mu.Unlock()
+
+	if result := limiter.Allow("a"); !result.Allowed || result.Remaining != 1 {
+		t.Errorf("expected a full bucket capped at the burst, got remaining %d", result.Remaining)
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	limiter := NewLimiter(Rule{Requests: 10, Period: time.Second, Burst: 10})
+	limiter.Allow("idle")
+	limiter.Allow("busy")
+
+	limiter.mu.Lock()
+	limiter.buckets["idle"].updated = time.Now().Add(-time.Hour)
+	limiter.lastSweep = time.Now().Add(-2 * idleSweepInterval)
+	limiter.mu.Unlock()
+
+	limiter.Allow("busy")
+
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Error("active bucket was swept")
+	}
+}
+
+func TestKeyFromContext(t *testing.T) {
+	user := &models.Principal{ID: "alice", Kind: models.PrincipalUser}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{
+			name: "anonymous caller",
+			ctx:  models.WithClientIP(context.Background(), "203.0.113.7"),
+			want: "ip:203.0.113.7",
+		},
+		{
+			name: "principal of the default tenant",
+			ctx:  models.WithPrincipal(context.Background(), user),
+			want: "principal:default/user:alice",
+		},
+		{
+			name: "same principal ID in another tenant",
+			ctx:  models.WithTenant(models.WithPrincipal(context.Background(), user), "acme"),
+			want: "principal:acme/user:alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeyFromContext(tt.ctx); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
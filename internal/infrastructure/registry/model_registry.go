@@ -11,14 +11,19 @@ import (
 
 // ModelRegistry implements the ModelRegistry interface
 type ModelRegistry struct {
-	models map[string]models.AIModel
-	mu     sync.RWMutex
+	models  map[string]models.AIModel
+	pricing map[string]models.Pricing
+	// tenants holds per-tenant model overrides keyed by tenant ID
+	tenants map[string]models.ModelOverrides
+	mu      sync.RWMutex
 }
 
 // NewModelRegistry creates a new model registry instance
 func NewModelRegistry() ports.ModelRegistry {
 	return &ModelRegistry{
-		models: make(map[string]models.AIModel),
+		models:  make(map[string]models.AIModel),
+		pricing: make(map[string]models.Pricing),
+		tenants: make(map[string]models.ModelOverrides),
 	}
 }
 
@@ -85,3 +90,108 @@ func (r *ModelRegistry) Validate(id string) error {
 
 	return nil
 }
+
+// SetPricing configures the pricing rules of a registered model
+func (r *ModelRegistry) SetPricing(id string, pricing models.Pricing) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.models[id]; !exists {
+		return apperrors.NewInvalidRequestError(
+			fmt.Sprintf("Model with ID %s not found", id),
+			nil,
+		)
+	}
+
+	r.pricing[id] = pricing
+	return nil
+}
+
+// Pricing returns the pricing rules of a model
+func (r *ModelRegistry) Pricing(id string) (models.Pricing, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	pricing, exists := r.pricing[id]
+	if !exists {
+		return models.Pricing{}, apperrors.NewNotFoundError(
+			fmt.Sprintf("No pricing configured for model %s", id),
+			nil,
+		)
+	}
+
+	return pricing, nil
+}
+
+// SetTenantOverrides restricts and customizes the models available to a tenant
+func (r *ModelRegistry) SetTenantOverrides(tenantID string, overrides models.ModelOverrides) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range overrides.AllowedModels {
+		if _, exists := r.models[id]; !exists {
+			return apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Model with ID %s not found", id),
+				nil,
+			)
+		}
+	}
+
+	for id := range overrides.Defaults {
+		if _, exists := r.models[id]; !exists {
+			return apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Model with ID %s not found", id),
+				nil,
+			)
+		}
+	}
+
+	r.tenants[tenantID] = overrides
+	return nil
+}
+
+// GetForTenant retrieves a model the tenant is allowed to use
+func (r *ModelRegistry) GetForTenant(tenantID, id string) (models.AIModel, error) {
+	model, err := r.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.tenants[tenantID].Allows(id) {
+		return nil, apperrors.NewForbiddenError(
+			fmt.Sprintf("Model %s is not enabled for this organization", id),
+			nil,
+		)
+	}
+
+	return model, nil
+}
+
+// ListForTenant returns the models the tenant is allowed to use
+func (r *ModelRegistry) ListForTenant(tenantID string) []models.AIModel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	overrides := r.tenants[tenantID]
+	allowed := make([]models.AIModel, 0, len(r.models))
+	for id, model := range r.models {
+		if overrides.Allows(id) {
+			allowed = append(allowed, model)
+		}
+	}
+
+	return allowed
+}
+
+// ApplyDefaults fills unset request parameters with the tenant's defaults for the model
+func (r *ModelRegistry) ApplyDefaults(tenantID string, req *models.Text2ImgRequest) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if defaults, ok := r.tenants[tenantID].Defaults[req.ModelID]; ok {
+		defaults.Apply(req)
+	}
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// APIKeyRepository implements the APIKeyRepository interface in memory,
+// persisting keys to a JSON file when a path is configured. Keys are only visible to
+// their own tenant, except through GetByHash.
+type APIKeyRepository struct {
+	path string
+	keys map[string]*models.APIKey
+	mu   sync.RWMutex
+}
+
+// NewAPIKeyRepository creates a new API key repository, loading existing keys from path.
+// An empty path keeps keys in memory only.
+func NewAPIKeyRepository(path string) (ports.APIKeyRepository, error) {
+	r := &APIKeyRepository{
+		path: path,
+		keys: make(map[string]*models.APIKey),
+	}
+
+	if path == "" {
+		return r, nil
+	}
+
+	var keys []*models.APIKey
+	if err := readJSONFile(path, &keys); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		r.keys[key.ID] = key
+	}
+
+	return r, nil
+}
+
+// Save creates or updates a key of the tenant of ctx
+func (r *APIKeyRepository) Save(ctx context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.keys[key.ID]
+	if exists && !inTenant(ctx, existing.TenantID) {
+		return apperrors.NewForbiddenError("API key belongs to another tenant", nil)
+	}
+
+	stored := *key
+	stored.TenantID = models.TenantFromContext(ctx)
+	r.keys[key.ID] = &stored
+
+	// Keep memory in step with the file, so a save that fails changes nothing
+	if err := r.persist(); err != nil {
+		if exists {
+			r.keys[key.ID] = existing
+		} else {
+			delete(r.keys, key.ID)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Get retrieves a key of the tenant of ctx by its ID
+func (r *APIKeyRepository) Get(ctx context.Context, id string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, exists := r.keys[id]
+	if !exists || !inTenant(ctx, key.TenantID) {
+		return nil, apperrors.NewNotFoundError(
+			fmt.Sprintf("API key with ID %s not found", id),
+			nil,
+		)
+	}
+
+	stored := *key
+	return &stored, nil
+}
+
+// GetByHash retrieves a key by the hash of its secret. It is not scoped to a tenant
+// because it is how the tenant of a request is established.
+func (r *APIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Hash == hash {
+			stored := *key
+			return &stored, nil
+		}
+	}
+
+	return nil, apperrors.NewNotFoundError("API key not found", nil)
+}
+
+// List returns all keys of the tenant of ctx, oldest first
+func (r *APIKeyRepository) List(ctx context.Context) ([]*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*models.APIKey, 0, len(r.keys))
+	for _, key := range r.sorted() {
+		if inTenant(ctx, key.TenantID) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// sorted returns copies of all keys ordered by creation time; callers must hold the lock
+func (r *APIKeyRepository) sorted() []*models.APIKey {
+	keys := make([]*models.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		stored := *key
+		keys = append(keys, &stored)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys
+}
+
+// persist writes all keys to the backing file; callers must hold the write lock
+func (r *APIKeyRepository) persist() error {
+	if r.path == "" {
+		return nil
+	}
+
+	if err := writeJSONFile(r.path, r.sorted()); err != nil {
+		return apperrors.NewInternalServerError("Failed to persist API keys", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// AuditRepository implements the AuditRepository interface in memory, appending every
+// event to a JSON lines file when a path is configured. Events are only visible to their
+// own tenant, but the hash chain spans all tenants. The chain is verified once when the
+// log is loaded; events appended afterwards are checked against the tail they extend.
+type AuditRepository struct {
+	path    string
+	hmacKey []byte
+	events  []*models.AuditEvent
+	// verifyErr is why the chain failed verification, if it did
+	verifyErr error
+	mu        sync.RWMutex
+}
+
+// NewAuditRepository creates a new audit repository, loading existing events from path.
+// An empty path keeps events in memory only. Events are hashed with HMAC-SHA256 under
+// hmacKey, so the chain cannot be rewritten without the key; without a key they are hashed
+// with plain SHA-256, which only detects accidental corruption.
+func NewAuditRepository(path string, hmacKey []byte) (ports.AuditRepository, error) {
+	r := &AuditRepository{path: path, hmacKey: hmacKey}
+
+	if path == "" {
+		return r, nil
+	}
+
+	err := readJSONLines(path, func(line []byte) error {
+		var event models.AuditEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			return err
+		}
+		r.events = append(r.events, &event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.verifyErr = r.verifyChain()
+	return r, nil
+}
+
+// Append chains an event of the tenant of ctx to the log, setting its sequence and hashes
+func (r *AuditRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *event
+	stored.TenantID = models.TenantFromContext(ctx)
+	stored.Sequence = 1
+	stored.PrevHash = ""
+	if n := len(r.events); n > 0 {
+		tail := r.events[n-1]
+		if r.verifyErr == nil {
+			if hash, err := r.hashEvent(tail); err != nil || hash != tail.Hash {
+				r.verifyErr = fmt.Errorf("audit event %d does not match its hash", tail.Sequence)
+			}
+		}
+		stored.Sequence = tail.Sequence + 1
+		stored.PrevHash = tail.Hash
+	}
+
+	hash, err := r.hashEvent(&stored)
+	if err != nil {
+		return apperrors.NewInternalServerError("Failed to hash audit event", err)
+	}
+	stored.Hash = hash
+
+	if r.path != "" {
+		if err := appendJSONLine(r.path, &stored); err != nil {
+			return apperrors.NewInternalServerError("Failed to persist audit event", err)
+		}
+	}
+
+	r.events = append(r.events, &stored)
+	*event = stored
+
+	return nil
+}
+
+// List returns the events of the tenant of ctx matching the filter, oldest first
+func (r *AuditRepository) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*models.AuditEvent
+	for _, event := range r.events {
+		if inTenant(ctx, event.TenantID) && filter.Matches(event) {
+			stored := *event
+			events = append(events, &stored)
+		}
+	}
+
+	return events, nil
+}
+
+// Verify reports why the hash chain failed verification, or nil if it holds
+func (r *AuditRepository) Verify(ctx context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.verifyErr
+}
+
+// verifyChain checks that every event links to its predecessor and matches its hash
+func (r *AuditRepository) verifyChain() error {
+	prevHash := ""
+	for i, event := range r.events {
+		if event.Sequence != int64(i+1) {
+			return fmt.Errorf("audit event %d has sequence %d", i+1, event.Sequence)
+		}
+		if event.PrevHash != prevHash {
+			return fmt.Errorf("audit event %d does not link to its predecessor", event.Sequence)
+		}
+
+		hash, err := r.hashEvent(event)
+		if err != nil {
+			return err
+		}
+		if hash != event.Hash {
+			return fmt.Errorf("audit event %d does not match its hash", event.Sequence)
+		}
+
+		prevHash = event.Hash
+	}
+
+	return nil
+}
+
+// hashEvent returns the hex-encoded HMAC-SHA256, or SHA-256 without a key, of the event's
+// JSON encoding without its own hash. The encoding includes PrevHash, which chains the
+// event to its predecessor.
+func (r *AuditRepository) hashEvent(event *models.AuditEvent) (string, error) {
+	unhashed := *event
+	unhashed.Hash = ""
+
+	data, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode audit event: %w", err)
+	}
+
+	if len(r.hmacKey) == 0 {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	mac := hmac.New(sha256.New, r.hmacKey)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"image/internal/domain/models"
+)
+
+var testAuditKey = []byte("audit-test-key")
+
+// writeAuditLog appends count events to a new log at a temporary path and returns the path
+func writeAuditLog(t *testing.T, count int) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	repo, err := NewAuditRepository(path, testAuditKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < count; i++ {
+		event := &models.AuditEvent{
+			ActorID:   "key_1",
+			Action:    models.AuditAPIKeyCreate,
+			Target:    "key_" + string(rune('a'+i)),
+			Outcome:   models.AuditSuccess,
+			CreatedAt: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC),
+		}
+		if err := repo.Append(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// editAuditLog rewrites the log's lines with edit
+func editAuditLog(t *testing.T, path string, edit func(events []*models.AuditEvent) []*models.AuditEvent) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []*models.AuditEvent
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		var event models.AuditEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, &event)
+	}
+
+	var out bytes.Buffer
+	for _, event := range edit(events) {
+		line, _ := json.Marshal(event)
+		out.Write(append(line, '\n'))
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rehash recomputes an event's hash the way someone without the HMAC key could
+func rehash(event *models.AuditEvent) {
+	event.Hash = ""
+	data, _ := json.Marshal(event)
+	sum := sha256.Sum256(data)
+	event.Hash = hex.EncodeToString(sum[:])
+}
+
+func TestAuditRepositoryVerify(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   []byte
+		edit  func(events []*models.AuditEvent) []*models.AuditEvent
+		valid bool
+	}{
+		{
+			name:  "untouched log",
+			key:   testAuditKey,
+			valid: true,
+		},
+		{
+			name: "edited field",
+			key:  testAuditKey,
+			edit: func(events []*models.AuditEvent) []*models.AuditEvent {
+				events[1].Outcome = models.AuditFailure
+				return events
+			},
+		},
+		{
+			name: "edited field with recomputed unkeyed hashes",
+			key:  testAuditKey,
+			edit: func(events []*models.AuditEvent) []*models.AuditEvent {
+				events[1].Target = "key_z"
+				prev := events[0].Hash
+				for _, event := range events[1:] {
+					event.PrevHash = prev
+					rehash(event)
+					prev = event.Hash
+				}
+				return events
+			},
+		},
+		{
+			name: "deleted event",
+			key:  testAuditKey,
+			edit: func(events []*models.AuditEvent) []*models.AuditEvent {
+				return append(events[:1], events[2:]...)
+			},
+		},
+		{
+			name: "reordered events",
+			key:  testAuditKey,
+			edit: func(events []*models.AuditEvent) []*models.AuditEvent {
+				events[1], events[2] = events[2], events[1]
+				return events
+			},
+		},
+		{
+			name: "verified with another key",
+			key:  []byte("another-key"),
+		},
+		{
+			name: "verified without a key",
+			key:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeAuditLog(t, 4)
+			if tt.edit != nil {
+				editAuditLog(t, path, tt.edit)
+			}
+
+			repo, err := NewAuditRepository(path, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = repo.Verify(context.Background())
+			if tt.valid && err != nil {
+				t.Fatalf("expected the chain to verify, got %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("expected the chain to fail verification")
+			}
+		})
+	}
+}
+
+func TestAuditRepositoryAppendExtendsChain(t *testing.T) {
+	path := writeAuditLog(t, 2)
+
+	repo, err := NewAuditRepository(path, testAuditKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := models.WithTenant(context.Background(), "acme")
+	event := &models.AuditEvent{Action: models.AuditAPIKeyRevoke, Target: "key_a", Outcome: models.AuditSuccess}
+	if err := repo.Append(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Sequence != 3 || event.PrevHash == "" || event.Hash == "" {
+		t.Fatalf("appended event was not chained: %+v", event)
+	}
+
+	// The appended event verifies after a reload, but only its tenant sees it
+	reloaded, err := NewAuditRepository(path, testAuditKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Verify(ctx); err != nil {
+		t.Fatalf("expected the extended chain to verify, got %v", err)
+	}
+
+	acme, _ := reloaded.List(ctx, models.AuditFilter{})
+	others, _ := reloaded.List(context.Background(), models.AuditFilter{})
+	if len(acme) != 1 || len(others) != 2 {
+		t.Errorf("expected 1 event for acme and 2 for the default tenant, got %d and %d", len(acme), len(others))
+	}
+}
@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// GenerationRepository implements the GenerationRepository interface in memory,
+// evicting the oldest generations once capacity is reached. Generations are only
+// visible to the tenant that created them.
+type GenerationRepository struct {
+	generations map[string]*models.Generation
+	order       []string
+	capacity    int
+	mu          sync.RWMutex
+}
+
+// NewGenerationRepository creates a new in-memory generation repository
+func NewGenerationRepository(capacity int) ports.GenerationRepository {
+	return &GenerationRepository{
+		generations: make(map[string]*models.Generation),
+		capacity:    capacity,
+	}
+}
+
+// Save stores a generation under the tenant of ctx
+func (r *GenerationRepository) Save(ctx context.Context, generation *models.Generation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, exists := r.generations[generation.ID]; !exists {
+		r.order = append(r.order, generation.ID)
+	} else if !inTenant(ctx, existing.TenantID) {
+		return apperrors.NewForbiddenError("Generation belongs to another tenant", nil)
+	}
+
+	generation.TenantID = models.TenantFromContext(ctx)
+	r.generations[generation.ID] = generation
+
+	for r.capacity > 0 && len(r.order) > r.capacity {
+		delete(r.generations, r.order[0])
+		r.order = r.order[1:]
+	}
+
+	return nil
+}
+
+// Get retrieves a generation of the tenant of ctx by its ID
+func (r *GenerationRepository) Get(ctx context.Context, id string) (*models.Generation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	generation, exists := r.generations[id]
+	if !exists || !inTenant(ctx, generation.TenantID) {
+		return nil, apperrors.NewNotFoundError(
+			fmt.Sprintf("Generation with ID %s not found", id),
+			nil,
+		)
+	}
+
+	return generation, nil
+}
@@ -0,0 +1,103 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// PendingJobRepository implements the PendingJobRepository interface in memory,
+// persisting jobs to a JSON file when a path is configured. Jobs carry their own tenant
+// and are listed across tenants, since the server resumes them on its own behalf.
+type PendingJobRepository struct {
+	path string
+	jobs map[string]*models.PendingJob
+	mu   sync.RWMutex
+}
+
+// NewPendingJobRepository creates a new pending job repository, loading existing jobs
+// from path. An empty path keeps jobs in memory only.
+func NewPendingJobRepository(path string) (ports.PendingJobRepository, error) {
+	r := &PendingJobRepository{
+		path: path,
+		jobs: make(map[string]*models.PendingJob),
+	}
+
+	if path == "" {
+		return r, nil
+	}
+
+	var jobs []*models.PendingJob
+	if err := readJSONFile(path, &jobs); err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		r.jobs[job.GenerationID] = job
+	}
+
+	return r, nil
+}
+
+// Save creates or updates a pending job
+func (r *PendingJobRepository) Save(ctx context.Context, job *models.PendingJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *job
+	r.jobs[job.GenerationID] = &stored
+
+	return r.persist()
+}
+
+// List returns all pending jobs, oldest first
+func (r *PendingJobRepository) List(ctx context.Context) ([]*models.PendingJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.sorted(), nil
+}
+
+// Delete removes a pending job by its generation ID; unknown IDs are ignored
+func (r *PendingJobRepository) Delete(ctx context.Context, generationID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[generationID]; !exists {
+		return nil
+	}
+	delete(r.jobs, generationID)
+
+	return r.persist()
+}
+
+// sorted returns copies of all jobs ordered by start time; callers must hold the lock
+func (r *PendingJobRepository) sorted() []*models.PendingJob {
+	jobs := make([]*models.PendingJob, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		stored := *job
+		jobs = append(jobs, &stored)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].StartedAt.Before(jobs[j].StartedAt)
+	})
+
+	return jobs
+}
+
+// persist writes all jobs to the backing file; callers must hold the write lock
+func (r *PendingJobRepository) persist() error {
+	if r.path == "" {
+		return nil
+	}
+
+	if err := writeJSONFile(r.path, r.sorted()); err != nil {
+		return apperrors.NewInternalServerError("Failed to persist pending jobs", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+)
+
+// inTenant reports whether a stored record belongs to the tenant of ctx.
+// Records stored before tenants existed belong to the default tenant.
+func inTenant(ctx context.Context, tenantID string) bool {
+	if tenantID == "" {
+		tenantID = models.DefaultTenantID
+	}
+	return tenantID == models.TenantFromContext(ctx)
+}
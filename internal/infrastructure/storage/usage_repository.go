@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// UsageRepository implements the UsageRepository interface in memory, appending every
+// record to a JSON lines file when a path is configured. Records are only visible to
+// their own tenant.
+type UsageRepository struct {
+	path    string
+	records []*models.UsageRecord
+	mu      sync.RWMutex
+}
+
+// NewUsageRepository creates a new usage repository, loading existing records from path.
+// An empty path keeps records in memory only.
+func NewUsageRepository(path string) (ports.UsageRepository, error) {
+	r := &UsageRepository{path: path}
+
+	if path == "" {
+		return r, nil
+	}
+
+	err := readJSONLines(path, func(line []byte) error {
+		var record models.UsageRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		r.records = append(r.records, &record)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Record appends a usage record of the tenant of ctx
+func (r *UsageRepository) Record(ctx context.Context, record *models.UsageRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *record
+	stored.TenantID = models.TenantFromContext(ctx)
+
+	if r.path != "" {
+		if err := appendJSONLine(r.path, &stored); err != nil {
+			return apperrors.NewInternalServerError("Failed to persist usage record", err)
+		}
+	}
+
+	r.records = append(r.records, &stored)
+
+	return nil
+}
+
+// List returns the records of the tenant of ctx, or of every tenant when the filter asks
+// for them, matching the filter, oldest first
+func (r *UsageRepository) List(ctx context.Context, filter models.UsageFilter) ([]*models.UsageRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var records []*models.UsageRecord
+	for _, record := range r.records {
+		if (filter.AllTenants || inTenant(ctx, record.TenantID)) && filter.Matches(record) {
+			stored := *record
+			if stored.TenantID == "" {
+				stored.TenantID = models.DefaultTenantID
+			}
+			records = append(records, &stored)
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+)
+
+// Generator records every generation attempt in the audit log. It implements the
+// ModelsLabService interface by wrapping the real generator.
+type Generator struct {
+	generator ports.ModelsLabService
+	log       ports.AuditLog
+}
+
+// NewGenerator creates a new auditing generator wrapping the generator
+func NewGenerator(generator ports.ModelsLabService, log ports.AuditLog) *Generator {
+	return &Generator{
+		generator: generator,
+		log:       log,
+	}
+}
+
+// GenerateImage generates the image and records the attempt. The target is the generation ID
+// on success and the requested model otherwise.
+func (g *Generator) GenerateImage(ctx context.Context, req *models.Text2ImgRequest) (*models.Text2ImgResponse, error) {
+	resp, err := g.generator.GenerateImage(ctx, req)
+
+	target := req.ModelID
+	if err == nil {
+		target = resp.GenerationID
+	}
+	g.log.Record(ctx, models.AuditGenerationCreate, target, err)
+
+	return resp, err
+}
+
+// RecordResumed records a generation that completed from an upstream job resumed after
+// a restart, on behalf of the caller that started it
+func (g *Generator) RecordResumed(ctx context.Context, req *models.Text2ImgRequest, resp *models.Text2ImgResponse) {
+	g.log.Record(ctx, models.AuditGenerationResume, resp.GenerationID, nil)
+}
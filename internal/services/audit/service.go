@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+)
+
+// Service implements the AuditService interface
+type Service struct {
+	repository ports.AuditRepository
+	logger     ports.Logger
+}
+
+// NewService creates a new audit service instance
+func NewService(repository ports.AuditRepository, logger ports.Logger) *Service {
+	return &Service{
+		repository: repository,
+		logger:     logger,
+	}
+}
+
+// Record appends an event for the caller in ctx. Failing to record is logged rather than
+// returned, so the audited action's own outcome is not changed by the audit log.
+func (s *Service) Record(ctx context.Context, action, target string, err error) {
+	actor := models.PrincipalOrAnonymous(ctx)
+
+	event := &models.AuditEvent{
+		ActorID:   actor.ID,
+		ActorName: actor.Name,
+		Action:    action,
+		Target:    target,
+		RequestID: models.RequestIDFromContext(ctx),
+		ClientIP:  models.ClientIPFromContext(ctx),
+		Outcome:   models.AuditSuccess,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err != nil {
+		event.Outcome = models.AuditFailure
+		event.Error = err.Error()
+	}
+
+	if err := s.repository.Append(ctx, event); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record audit event", err,
+			"action", action,
+			"target", target,
+			"actor_id", actor.ID,
+		)
+	}
+}
+
+// Query returns the events matching the filter and whether the log verified
+func (s *Service) Query(ctx context.Context, filter models.AuditFilter) (*models.AuditLogResponse, error) {
+	events, err := s.repository.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.AuditLogResponse{
+		ChainValid: true,
+		Events:     make([]models.AuditEvent, len(events)),
+	}
+	for i, event := range events {
+		response.Events[i] = *event
+	}
+
+	if err := s.repository.Verify(ctx); err != nil {
+		response.ChainValid = false
+	}
+
+	return response, nil
+}
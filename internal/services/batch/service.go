@@ -0,0 +1,169 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// Service implements the BatchService interface
+type Service struct {
+	generator      ports.ModelsLabService
+	logger         ports.Logger
+	maxItems       int
+	maxConcurrency int
+}
+
+// ServiceOption defines a function type for service configuration
+type ServiceOption func(*Service)
+
+// NewService creates a new batch service instance
+func NewService(generator ports.ModelsLabService, logger ports.Logger, opts ...ServiceOption) *Service {
+	s := &Service{
+		generator:      generator,
+		logger:         logger,
+		maxItems:       16,
+		maxConcurrency: 2,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithMaxItems sets the maximum number of items accepted in one batch
+func WithMaxItems(maxItems int) ServiceOption {
+	return func(s *Service) {
+		if maxItems > 0 {
+			s.maxItems = maxItems
+		}
+	}
+}
+
+// WithMaxConcurrency sets how many items are generated at the same time
+func WithMaxConcurrency(maxConcurrency int) ServiceOption {
+	return func(s *Service) {
+		if maxConcurrency > 0 {
+			s.maxConcurrency = maxConcurrency
+		}
+	}
+}
+
+// Generate fans the batch out to the image generator and collects per-item results
+func (s *Service) Generate(ctx context.Context, req *models.BatchRequest) (*models.BatchResponse, error) {
+	// Check the size before expanding, so an oversized seed range is never allocated
+	if count := req.ItemCount(); count > s.maxItems {
+		return nil, apperrors.NewInvalidRequestError(
+			fmt.Sprintf("Batch contains %d items, maximum is %d", count, s.maxItems),
+			nil,
+		)
+	}
+
+	items, err := req.Expand()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GenerateItems(ctx, items), nil
+}
+
+// GenerateItems generates already expanded requests without applying the batch size limit
+func (s *Service) GenerateItems(ctx context.Context, items []models.Text2ImgRequest) *models.BatchResponse {
+	s.logger.InfoContext(ctx, "Processing batch request",
+		"items", len(items),
+		"concurrency", s.maxConcurrency,
+	)
+
+	results := make([]models.BatchItemResult, len(items))
+	sem := make(chan struct{}, s.maxConcurrency)
+	var wg sync.WaitGroup
+
+	for i := range items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i] = s.failedResult(i, &items[i], apperrors.NewExternalAPIError("Request cancelled", ctx.Err()))
+				return
+			}
+
+			results[i] = s.generateItem(ctx, i, &items[i])
+		}(i)
+	}
+
+	wg.Wait()
+
+	response := &models.BatchResponse{
+		Total: len(results),
+		Items: results,
+	}
+	for _, result := range results {
+		if result.Status == models.BatchStatusSuccess {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+
+	switch {
+	case response.Failed == 0:
+		response.Status = models.BatchStatusSuccess
+	case response.Succeeded == 0:
+		response.Status = models.BatchStatusError
+	default:
+		response.Status = models.BatchStatusPartial
+	}
+
+	s.logger.InfoContext(ctx, "Batch request completed",
+		"succeeded", response.Succeeded,
+		"failed", response.Failed,
+	)
+
+	return response
+}
+
+// generateItem generates a single batch item and converts the outcome into a result
+func (s *Service) generateItem(ctx context.Context, index int, item *models.Text2ImgRequest) models.BatchItemResult {
+	resp, err := s.generator.GenerateImage(ctx, item)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Batch item failed", err, "index", index)
+		return s.failedResult(index, item, err)
+	}
+
+	output := resp.Output
+	if len(output) == 0 {
+		output = resp.Images
+	}
+
+	return models.BatchItemResult{
+		Index:          index,
+		Status:         models.BatchStatusSuccess,
+		Prompt:         item.Prompt,
+		Seed:           item.Seed,
+		Output:         output,
+		GenerationTime: resp.GenerationTime,
+		ID:             resp.ID,
+	}
+}
+
+// failedResult builds the result for an item that could not be generated
+func (s *Service) failedResult(index int, item *models.Text2ImgRequest, err error) models.BatchItemResult {
+	errResp := models.NewErrorResponse(err)
+	return models.BatchItemResult{
+		Index:  index,
+		Status: models.BatchStatusError,
+		Prompt: item.Prompt,
+		Seed:   item.Seed,
+		Error:  &errResp,
+	}
+}
@@ -0,0 +1,104 @@
+package estimate
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+)
+
+// historySize is how many of a model's most recent generations feed the duration estimate
+const historySize = 200
+
+// Service implements the EstimateService interface
+type Service struct {
+	registry  ports.ModelRegistry
+	validator ports.Validator
+	usage     ports.UsageRepository
+	logger    ports.Logger
+}
+
+// NewService creates a new cost estimation service
+func NewService(registry ports.ModelRegistry, validator ports.Validator, usage ports.UsageRepository, logger ports.Logger) *Service {
+	return &Service{
+		registry:  registry,
+		validator: validator,
+		usage:     usage,
+		logger:    logger,
+	}
+}
+
+// Estimate returns the expected credits and duration of a request
+func (s *Service) Estimate(ctx context.Context, req *models.Text2ImgRequest) (*models.CostEstimate, error) {
+	tenantID := models.TenantFromContext(ctx)
+	s.registry.ApplyDefaults(tenantID, req)
+
+	if err := s.validator.Validate(req); err != nil {
+		return nil, err
+	}
+
+	model, err := s.registry.GetForTenant(tenantID, req.ModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := model.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+
+	pricing, err := s.registry.Pricing(req.ModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := pricing.Estimate(req)
+	estimate := &models.CostEstimate{
+		ModelID:   req.ModelID,
+		Credits:   breakdown.Total,
+		Breakdown: breakdown,
+	}
+
+	if estimate.ExpectedDuration, err = s.expectedDuration(ctx, req.ModelID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to load generation history", err, "model_id", req.ModelID)
+	}
+
+	return estimate, nil
+}
+
+// expectedDuration summarizes the generation time of the model's recent generations.
+// It returns nil when the model has no history yet.
+func (s *Service) expectedDuration(ctx context.Context, modelID string) (*models.DurationEstimate, error) {
+	records, err := s.usage.List(ctx, models.UsageFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	var durations []float64
+	for i := len(records) - 1; i >= 0 && len(durations) < historySize; i-- {
+		if records[i].ModelID == modelID && records[i].GenerationTime > 0 {
+			durations = append(durations, records[i].GenerationTime)
+		}
+	}
+
+	if len(durations) == 0 {
+		return nil, nil
+	}
+
+	sort.Float64s(durations)
+	return &models.DurationEstimate{
+		MedianSeconds: percentile(durations, 0.5),
+		P90Seconds:    percentile(durations, 0.9),
+		SampleSize:    len(durations),
+	}, nil
+}
+
+// percentile returns the nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,47 @@
+package generations
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+)
+
+// Service implements the GenerationService interface
+type Service struct {
+	repository ports.GenerationRepository
+	generator  ports.ModelsLabService
+	logger     ports.Logger
+}
+
+// NewService creates a new generation service instance
+func NewService(repository ports.GenerationRepository, generator ports.ModelsLabService, logger ports.Logger) *Service {
+	return &Service{
+		repository: repository,
+		generator:  generator,
+		logger:     logger,
+	}
+}
+
+// Get returns a recorded generation by its ID
+func (s *Service) Get(ctx context.Context, id string) (*models.Generation, error) {
+	return s.repository.Get(ctx, id)
+}
+
+// Reproduce replays a recorded generation with its exact parameters and seed
+func (s *Service) Reproduce(ctx context.Context, id string) (*models.Text2ImgResponse, error) {
+	generation, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := generation.ReproduceRequest()
+
+	s.logger.InfoContext(ctx, "Reproducing generation",
+		"generation_id", id,
+		"model_id", req.ModelID,
+		"seed", *req.Seed,
+	)
+
+	return s.generator.GenerateImage(ctx, &req)
+}
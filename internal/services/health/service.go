@@ -0,0 +1,115 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	"image/pkg/version"
+)
+
+// defaultProbeTimeout bounds how long a single probe may take
+const defaultProbeTimeout = 2 * time.Second
+
+// Service implements the HealthService interface
+type Service struct {
+	probes  []ports.HealthProbe
+	timeout time.Duration
+	logger  ports.Logger
+}
+
+// ServiceOption defines a function type for service configuration
+type ServiceOption func(*Service)
+
+// NewService creates a new health service instance
+func NewService(logger ports.Logger, opts ...ServiceOption) *Service {
+	s := &Service{
+		timeout: defaultProbeTimeout,
+		logger:  logger,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithProbes adds dependency probes checked for readiness
+func WithProbes(probes ...ports.HealthProbe) ServiceOption {
+	return func(s *Service) {
+		s.probes = append(s.probes, probes...)
+	}
+}
+
+// WithProbeTimeout bounds how long a single probe may take before it counts as failed
+func WithProbeTimeout(timeout time.Duration) ServiceOption {
+	return func(s *Service) {
+		if timeout > 0 {
+			s.timeout = timeout
+		}
+	}
+}
+
+// Live reports that the process is up, without checking dependencies
+func (s *Service) Live(ctx context.Context) *models.HealthReport {
+	return newReport(models.HealthOK)
+}
+
+// Ready runs every probe concurrently and reports degraded when any of them fails
+func (s *Service) Ready(ctx context.Context) *models.HealthReport {
+	checks := make([]models.HealthCheck, len(s.probes))
+
+	var wg sync.WaitGroup
+	for i, probe := range s.probes {
+		wg.Add(1)
+		go func(i int, probe ports.HealthProbe) {
+			defer wg.Done()
+			checks[i] = s.check(ctx, probe)
+		}(i, probe)
+	}
+	wg.Wait()
+
+	report := newReport(models.HealthOK)
+	report.Checks = checks
+	for _, check := range checks {
+		if check.Status != models.HealthOK {
+			report.Status = models.HealthDegraded
+			s.logger.InfoContext(ctx, "Readiness check failed", "check", check.Name, "error", check.Error)
+		}
+	}
+
+	return report
+}
+
+// check runs a single probe within the probe timeout
+func (s *Service) check(ctx context.Context, probe ports.HealthProbe) models.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := probe.Check(ctx)
+
+	check := models.HealthCheck{
+		Name:      probe.Name(),
+		Status:    models.HealthOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		check.Status = models.HealthFailed
+		check.Error = err.Error()
+	}
+	return check
+}
+
+// newReport creates a report stamped with the build information
+func newReport(status string) *models.HealthReport {
+	return &models.HealthReport{
+		Status:    status,
+		Timestamp: time.Now().UTC(),
+		Version:   version.Version,
+		Commit:    version.Commit,
+	}
+}
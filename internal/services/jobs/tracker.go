@@ -0,0 +1,232 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"image/internal/domain/models"
+	apperrors "image/pkg/errors"
+)
+
+// ErrCancelled is the context cause set when a job is cancelled through the API
+var ErrCancelled = errors.New("job cancelled")
+
+// ErrShutdown is the context cause set when a job is abandoned because the server is stopping
+var ErrShutdown = errors.New("server shutting down")
+
+// job is an in-flight generation and the function that cancels its context
+type job struct {
+	info   models.Job
+	cancel context.CancelCauseFunc
+}
+
+// Tracker keeps track of in-flight generations so they can be listed and cancelled, and
+// drained when the server stops
+type Tracker struct {
+	jobs map[string]*job
+	// draining refuses new jobs; idle is closed once the last job finishes while draining
+	draining bool
+	idle     chan struct{}
+	mu       sync.RWMutex
+}
+
+// NewTracker creates a new job tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		jobs: make(map[string]*job),
+	}
+}
+
+// Start registers a job of the principal and tenant of ctx and returns a context that is cancelled when the
+// job is cancelled. New jobs are refused once the tracker is draining.
+func (t *Tracker) Start(ctx context.Context, id, modelID string) (context.Context, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.draining {
+		return nil, apperrors.NewUnavailableError("Server is shutting down, retry the request", nil)
+	}
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+
+	t.jobs[id] = &job{
+		info: models.Job{
+			ID:          id,
+			TenantID:    models.TenantFromContext(ctx),
+			PrincipalID: models.PrincipalOrAnonymous(ctx).ID,
+			ModelID:     modelID,
+			Status:      models.JobStatusRunning,
+			StartedAt:   time.Now().UTC(),
+		},
+		cancel: cancel,
+	}
+
+	return jobCtx, nil
+}
+
+// SetUpstreamID records the ModelsLab ID of a job that is processing upstream and the name
+// of the pooled key that created it, if any
+func (t *Tracker) SetUpstreamID(id string, upstreamID int64, keyName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if j, ok := t.jobs[id]; ok {
+		j.info.UpstreamID = upstreamID
+		j.info.UpstreamKey = keyName
+		if j.info.Status == models.JobStatusRunning {
+			j.info.Status = models.JobStatusProcessing
+		}
+	}
+}
+
+// Finish removes a job from the tracker and releases its context
+func (t *Tracker) Finish(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if j, ok := t.jobs[id]; ok {
+		j.cancel(context.Canceled)
+		delete(t.jobs, id)
+	}
+
+	if t.draining && len(t.jobs) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// Drain refuses new jobs and waits until the in-flight ones finish or ctx is done
+func (t *Tracker) Drain(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.draining {
+		t.draining = true
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	if len(t.jobs) == 0 && idle != nil {
+		close(idle)
+		t.idle = nil
+	}
+	t.mu.Unlock()
+
+	if idle == nil {
+		return nil
+	}
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Draining reports whether the tracker has stopped accepting new jobs
+func (t *Tracker) Draining() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.draining
+}
+
+// Abandon cancels every job still in flight and returns snapshots of them, oldest first
+func (t *Tracker) Abandon() []models.Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	abandoned := make([]models.Job, 0, len(t.jobs))
+	for _, j := range t.jobs {
+		j.info.Status = models.JobStatusAbandoned
+		j.cancel(ErrShutdown)
+		abandoned = append(abandoned, j.info)
+	}
+
+	sort.Slice(abandoned, func(i, k int) bool {
+		return abandoned[i].StartedAt.Before(abandoned[k].StartedAt)
+	})
+
+	return abandoned
+}
+
+// Cancel cancels a job of the tenant of ctx by its job ID or upstream ID and returns a snapshot
+// of it. Only the principal that started the job or an admin of its tenant may cancel it.
+func (t *Tracker) Cancel(ctx context.Context, id string) (*models.Job, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	j := t.find(id)
+	if j == nil || j.info.TenantID != models.TenantFromContext(ctx) {
+		return nil, apperrors.NewNotFoundError(
+			fmt.Sprintf("No in-flight generation with ID %s", id),
+			nil,
+		)
+	}
+
+	if principal := models.PrincipalOrAnonymous(ctx); principal.ID != j.info.PrincipalID && !principal.HasScope(models.ScopeAdmin) {
+		return nil, apperrors.NewForbiddenError(
+			fmt.Sprintf("Only the caller that started generation %s or an admin may cancel it", j.info.ID),
+			nil,
+		)
+	}
+
+	j.info.Status = models.JobStatusCancelled
+	j.cancel(ErrCancelled)
+
+	info := j.info
+	return &info, nil
+}
+
+// List returns the in-flight jobs of the tenant of ctx, oldest first
+func (t *Tracker) List(ctx context.Context) []models.Job {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	tenantID := models.TenantFromContext(ctx)
+	jobs := make([]models.Job, 0, len(t.jobs))
+	for _, j := range t.jobs {
+		if j.info.TenantID == tenantID {
+			jobs = append(jobs, j.info)
+		}
+	}
+
+	sort.Slice(jobs, func(i, k int) bool {
+		return jobs[i].StartedAt.Before(jobs[k].StartedAt)
+	})
+
+	return jobs
+}
+
+// find looks a job up by its job ID, falling back to its upstream ID
+func (t *Tracker) find(id string) *job {
+	if j, ok := t.jobs[id]; ok {
+		return j
+	}
+
+	upstreamID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	for _, j := range t.jobs {
+		if j.info.UpstreamID == upstreamID {
+			return j
+		}
+	}
+
+	return nil
+}
+
+// IsCancelled reports whether ctx was cancelled through the API rather than by the client
+func IsCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrCancelled)
+}
+
+// IsShutdown reports whether ctx was cancelled because the server is stopping
+func IsShutdown(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrShutdown)
+}
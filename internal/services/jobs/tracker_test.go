@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"image/internal/domain/models"
+	apperrors "image/pkg/errors"
+)
+
+// callerContext returns a context for a principal of the tenant with the given scopes
+func callerContext(tenantID, principalID string, scopes ...models.Scope) context.Context {
+	ctx := models.WithPrincipal(context.Background(), &models.Principal{ID: principalID, TenantID: tenantID, Scopes: scopes})
+	return models.WithTenant(ctx, tenantID)
+}
+
+func TestTrackerCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		caller   context.Context
+		id       string
+		wantCode apperrors.ErrorCode
+	}{
+		{name: "owner", caller: callerContext("acme", "alice", models.ScopeGenerate), id: "gen_1"},
+		{name: "owner by upstream ID", caller: callerContext("acme", "alice", models.ScopeGenerate), id: "42"},
+		{name: "admin of the tenant", caller: callerContext("acme", "ops", models.ScopeAdmin), id: "gen_1"},
+		{name: "another member", caller: callerContext("acme", "bob", models.ScopeGenerate), id: "gen_1", wantCode: apperrors.ErrForbidden},
+		{name: "admin of another tenant", caller: callerContext("globex", "ops", models.ScopeAdmin), id: "gen_1", wantCode: apperrors.ErrNotFound},
+		{name: "unknown job", caller: callerContext("acme", "alice", models.ScopeGenerate), id: "gen_2", wantCode: apperrors.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewTracker()
+			jobCtx, err := tracker.Start(callerContext("acme", "alice", models.ScopeGenerate), "gen_1", "flux")
+			if err != nil {
+				t.Fatal(err)
+			}
+			tracker.SetUpstreamID("gen_1", 42, "")
+
+			job, err := tracker.Cancel(tt.caller, tt.id)
+
+			if tt.wantCode != "" {
+				var appErr *apperrors.AppError
+				if !errors.As(err, &appErr) || appErr.Code != tt.wantCode {
+					t.Fatalf("expected a %s error, got %v", tt.wantCode, err)
+				}
+				if IsCancelled(jobCtx) {
+					t.Error("job was cancelled anyway")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.Status != models.JobStatusCancelled || job.PrincipalID != "alice" {
+				t.Errorf("unexpected job snapshot %+v", job)
+			}
+			if !IsCancelled(jobCtx) {
+				t.Error("job context was not cancelled")
+			}
+		})
+	}
+}
+
+func TestTrackerCancelWithoutAuthentication(t *testing.T) {
+	tracker := NewTracker()
+	jobCtx, err := tracker.Start(context.Background(), "gen_1", "flux")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tracker.Cancel(context.Background(), "gen_1"); err != nil {
+		t.Fatalf("expected anonymous callers to cancel anonymous jobs, got %v", err)
+	}
+	if !IsCancelled(jobCtx) {
+		t.Error("job context was not cancelled")
+	}
+}
@@ -0,0 +1,46 @@
+package loglevel
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// auditTarget names the log level in audit events
+const auditTarget = "log_level"
+
+// Service implements the LogLevelService interface
+type Service struct {
+	control ports.LogLevelController
+	audit   ports.AuditLog
+	logger  ports.Logger
+}
+
+// NewService creates a new log level service instance
+func NewService(control ports.LogLevelController, audit ports.AuditLog, logger ports.Logger) *Service {
+	return &Service{
+		control: control,
+		audit:   audit,
+		logger:  logger,
+	}
+}
+
+// Level returns the current log level
+func (s *Service) Level(ctx context.Context) *models.LogLevel {
+	return &models.LogLevel{Level: s.control.Level()}
+}
+
+// SetLevel changes the log level of the running server
+func (s *Service) SetLevel(ctx context.Context, req *models.LogLevel) (*models.LogLevel, error) {
+	previous := s.control.Level()
+	err := s.control.SetLevel(req.Level)
+	s.audit.Record(ctx, models.AuditConfigLogLevel, auditTarget, err)
+	if err != nil {
+		return nil, apperrors.NewInvalidRequestError("Invalid log level", err)
+	}
+
+	s.logger.InfoContext(ctx, "Log level changed", "from", previous, "to", s.control.Level())
+	return s.Level(ctx), nil
+}
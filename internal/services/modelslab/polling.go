@@ -0,0 +1,57 @@
+package modelslab
+
+import (
+	"math"
+	"time"
+)
+
+// PollingStrategy controls how pollForCompletion waits for processing jobs
+type PollingStrategy struct {
+	// MaxWait is the maximum wall time spent polling a single job
+	MaxWait time.Duration
+	// BaseInterval is the delay before the first poll when the upstream gives no ETA
+	BaseInterval time.Duration
+	// MaxInterval caps the delay between two polls
+	MaxInterval time.Duration
+	// Multiplier grows the delay after each poll without an ETA
+	Multiplier float64
+	// Endpoints are the candidate status paths, with {id} as placeholder for the upstream ID.
+	// The first one that answers is remembered and tried first on later polls.
+	Endpoints []string
+}
+
+// DefaultPollingStrategy returns the polling strategy used when none is configured
+func DefaultPollingStrategy() PollingStrategy {
+	return PollingStrategy{
+		MaxWait:      3 * time.Minute,
+		BaseInterval: 1 * time.Second,
+		MaxInterval:  15 * time.Second,
+		Multiplier:   1.5,
+		Endpoints: []string{
+			"/status/{id}",
+			"/images/status/{id}",
+			"/images/text2img/{id}",
+		},
+	}
+}
+
+// NextDelay returns how long to wait before the given poll attempt (starting at 0).
+// A positive upstream ETA in seconds takes precedence over exponential backoff.
+func (p PollingStrategy) NextDelay(attempt int, eta float64) time.Duration {
+	var delay float64
+	if eta > 0 {
+		delay = eta * float64(time.Second)
+	} else {
+		delay = float64(p.BaseInterval) * math.Pow(p.Multiplier, float64(attempt))
+	}
+
+	// Clamp in floating point so large attempts cannot overflow time.Duration
+	if delay < float64(p.BaseInterval) {
+		delay = float64(p.BaseInterval)
+	}
+	if p.MaxInterval > 0 && delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+
+	return time.Duration(delay)
+}
@@ -3,54 +3,206 @@ package modelslab
 import (
 	"context"
 	"fmt"
-	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"image/internal/domain/models"
 	"image/internal/domain/ports"
+	"image/internal/infrastructure/ratelimit"
+	"image/internal/infrastructure/tracing"
 	"image/internal/infrastructure/validation"
+	"image/internal/services/jobs"
 	apperrors "image/pkg/errors"
+	"image/pkg/ids"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer creates the spans of generations and their polling
+var tracer = otel.Tracer("image/internal/services/modelslab")
+
 const (
 	text2ImgEndpoint = "/images/text2img"
+
+	// maxSeed bounds server-generated seeds to the 32-bit range ModelsLab accepts
+	maxSeed = 1 << 32
+
+	// defaultMaxPixels and defaultMaxSamples bound requests regardless of the model
+	defaultMaxPixels  = 1024 * 1024
+	defaultMaxSamples = 4
 )
 
 // Service implements the ModelsLabService interface
 type Service struct {
-	client    ports.HTTPClient
-	validator *validation.Validator
-	logger    ports.Logger
-	registry  ports.ModelRegistry
+	client      ports.HTTPClient
+	validator   *validation.Validator
+	logger      ports.Logger
+	registry    ports.ModelRegistry
+	generations ports.GenerationRepository
+	jobs        *jobs.Tracker
+	polling     PollingStrategy
+	// statusEndpoint is the index of the polling endpoint that last answered
+	statusEndpoint atomic.Int32
+	// cancelEndpoint is the upstream path used to cancel a job, with {id} as placeholder
+	cancelEndpoint string
+	// modelLimits holds per-model rate limiters keyed by model ID
+	modelLimits map[string]*ratelimit.Limiter
+	access      ports.AccessPolicy
+	keys        ports.UpstreamKeyPool
+	metrics     ports.Metrics
+	// pending records jobs processing upstream so they can be resumed after a restart
+	pending ports.PendingJobRepository
+	// maxPixels and maxSamples bound every request on top of the model's own limits
+	maxPixels  int
+	maxSamples int
 }
 
+// ServiceOption defines a function type for service configuration
+type ServiceOption func(*Service)
+
 // NewService creates a new ModelsLab service instance
-func NewService(client ports.HTTPClient, validator *validation.Validator, logger ports.Logger, registry ports.ModelRegistry) *Service {
-	// Initialize registry with supported models
-	registry.Register(models.NewMidjourneyModel())
-	registry.Register(models.NewFluxModel())
+func NewService(client ports.HTTPClient, validator *validation.Validator, logger ports.Logger, registry ports.ModelRegistry, opts ...ServiceOption) *Service {
+	// Register the built-in models unless the model catalog already defines them
+	for _, model := range []models.AIModel{models.NewMidjourneyModel(), models.NewFluxModel()} {
+		if _, err := registry.Get(model.ID()); err != nil {
+			registry.Register(model)
+		}
+	}
 
-	return &Service{
-		client:    client,
-		validator: validator,
-		logger:    logger,
-		registry:  registry,
+	s := &Service{
+		client:     client,
+		validator:  validator,
+		logger:     logger,
+		registry:   registry,
+		jobs:       jobs.NewTracker(),
+		polling:    DefaultPollingStrategy(),
+		maxPixels:  defaultMaxPixels,
+		maxSamples: defaultMaxSamples,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithGenerationRepository records every successful generation in the given repository
+func WithGenerationRepository(generations ports.GenerationRepository) ServiceOption {
+	return func(s *Service) {
+		s.generations = generations
+	}
+}
+
+// WithJobTracker sets the tracker used to register in-flight generations
+func WithJobTracker(tracker *jobs.Tracker) ServiceOption {
+	return func(s *Service) {
+		s.jobs = tracker
+	}
+}
+
+// WithCancelEndpoint sets the upstream path used to cancel jobs, e.g. "/images/cancel/{id}".
+// Cancellation stays local to this server when no endpoint is configured.
+func WithCancelEndpoint(endpoint string) ServiceOption {
+	return func(s *Service) {
+		s.cancelEndpoint = endpoint
+	}
+}
+
+// WithPollingStrategy sets how processing jobs are polled until completion
+func WithPollingStrategy(strategy PollingStrategy) ServiceOption {
+	return func(s *Service) {
+		if len(strategy.Endpoints) == 0 {
+			strategy.Endpoints = DefaultPollingStrategy().Endpoints
+		}
+		s.polling = strategy
+	}
+}
+
+// WithModelRateLimits limits how often each caller may generate with the given models
+func WithModelRateLimits(rules map[string]ratelimit.Rule) ServiceOption {
+	return func(s *Service) {
+		s.modelLimits = make(map[string]*ratelimit.Limiter, len(rules))
+		for modelID, rule := range rules {
+			s.modelLimits[modelID] = ratelimit.NewLimiter(rule)
+		}
+	}
+}
+
+// WithAccessPolicy restricts the models and capabilities each caller may use
+func WithAccessPolicy(access ports.AccessPolicy) ServiceOption {
+	return func(s *Service) {
+		s.access = access
+	}
+}
+
+// WithKeyPool charges the estimated credits of every generation to the upstream key it used
+func WithKeyPool(keys ports.UpstreamKeyPool) ServiceOption {
+	return func(s *Service) {
+		s.keys = keys
+	}
+}
+
+// WithMetrics records in-flight generations and polling attempts and durations per model
+func WithMetrics(metrics ports.Metrics) ServiceOption {
+	return func(s *Service) {
+		s.metrics = metrics
+	}
+}
+
+// WithRequestLimits bounds the pixels and samples of every request, whatever the model
+func WithRequestLimits(maxPixels, maxSamples int) ServiceOption {
+	return func(s *Service) {
+		if maxPixels > 0 {
+			s.maxPixels = maxPixels
+		}
+		if maxSamples > 0 {
+			s.maxSamples = maxSamples
+		}
+	}
+}
+
+// WithPendingJobRepository records jobs while they process upstream so that polling can
+// resume after a restart
+func WithPendingJobRepository(pending ports.PendingJobRepository) ServiceOption {
+	return func(s *Service) {
+		s.pending = pending
 	}
 }
 
 // GenerateImage generates an image from text using the ModelsLab API
-func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest) (*models.Text2ImgResponse, error) {
+func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest) (_ *models.Text2ImgResponse, err error) {
+	ctx, span := tracer.Start(ctx, "modelslab.Service.GenerateImage", trace.WithAttributes(
+		attribute.String("model_id", req.ModelID),
+		attribute.Int("width", req.Width),
+		attribute.Int("height", req.Height),
+		attribute.Int("samples", req.Samples),
+	))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	// Log the incoming request
-	s.logger.Info("Processing text-to-image request",
+	s.logger.InfoContext(ctx, "Processing text-to-image request",
 		"model_id", req.ModelID,
 		"width", req.Width,
 		"height", req.Height,
 		"samples", req.Samples,
 	)
 
+	// Fill in the tenant's defaults before validating
+	tenantID := models.TenantFromContext(ctx)
+	s.registry.ApplyDefaults(tenantID, req)
+
 	// Validate the request
 	if err := s.validateRequest(req); err != nil {
-		s.logger.Error("Request validation failed", err)
+		s.logger.ErrorContext(ctx, "Request validation failed", err)
 		return nil, err
 	}
 
@@ -64,20 +216,63 @@ func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest
 	}
 
 	// Get and validate the model
-	model, err := s.registry.Get(req.ModelID)
+	model, err := s.registry.GetForTenant(tenantID, req.ModelID)
 	if err != nil {
-		s.logger.Error("Invalid model ID", err)
+		s.logger.ErrorContext(ctx, "Invalid model ID", err)
 		return nil, err
 	}
 
 	// Validate request against model capabilities
 	if err := model.ValidateRequest(req); err != nil {
-		s.logger.Error("Request validation failed for model", err,
+		s.logger.ErrorContext(ctx, "Request validation failed for model", err,
 			"model_id", req.ModelID,
 		)
 		return nil, err
 	}
 
+	// Enforce the caller's role policies on the validated request
+	if s.access != nil {
+		if err := s.access.Authorize(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	// Enforce per-model rate limits before spending upstream credits
+	if limiter, ok := s.modelLimits[req.ModelID]; ok {
+		key := ratelimit.KeyFromContext(ctx)
+		if result := limiter.Allow(key); !result.Allowed {
+			s.logger.InfoContext(ctx, "Model rate limit exceeded",
+				"model_id", req.ModelID,
+				"limit_key", key,
+			)
+			return nil, apperrors.NewRateLimitedError(
+				fmt.Sprintf("Rate limit exceeded for model %s, retry later", req.ModelID),
+				result.RetryAfter,
+			)
+		}
+	}
+
+	// Register the generation so it can be listed and cancelled while in flight
+	generationID := ids.New("gen")
+	span.SetAttributes(attribute.String("generation_id", generationID))
+	ctx, err = s.jobs.Start(ctx, generationID, req.ModelID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.jobs.Finish(generationID)
+
+	if s.metrics != nil {
+		s.metrics.GenerationStarted(req.ModelID)
+		defer s.metrics.GenerationFinished(req.ModelID)
+	}
+
+	// Pin the seed so the generation can always be reproduced
+	if req.Seed == nil {
+		seed := rand.Int63n(maxSeed)
+		req.Seed = &seed
+		s.logger.DebugContext(ctx, "Generated seed for request", "seed", seed)
+	}
+
 	// Convert request to ModelsLab API format
 	apiReq := &models.ModelsLabAPIRequest{
 		ModelID:           req.ModelID,
@@ -106,8 +301,13 @@ func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest
 		TrackID:           req.TrackID,
 	}
 
+	// Correlate the upstream job with this request unless the caller tracks it themselves
+	if apiReq.TrackID == "" {
+		apiReq.TrackID = models.RequestIDFromContext(ctx)
+	}
+
 	// Log the converted request for debugging
-	s.logger.Debug("Converted API request",
+	s.logger.DebugContext(ctx, "Converted API request",
 		"model_id", apiReq.ModelID,
 		"width", apiReq.Width,
 		"height", apiReq.Height,
@@ -121,7 +321,13 @@ func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest
 
 	// Call the ModelsLab API
 	if err := s.client.Post(ctx, text2ImgEndpoint, apiReq, &response); err != nil {
-		s.logger.Error("Failed to generate image", err,
+		if jobs.IsCancelled(ctx) {
+			return nil, apperrors.NewCancelledError("Generation cancelled", err)
+		}
+		if jobs.IsShutdown(ctx) {
+			return nil, apperrors.NewUnavailableError("Server shut down before the generation finished", err)
+		}
+		s.logger.ErrorContext(ctx, "Failed to generate image", err,
 			"model_id", req.ModelID,
 		)
 		return nil, fmt.Errorf("failed to generate image: %w", err)
@@ -129,111 +335,469 @@ func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest
 
 	// Handle initial response
 	if err := s.validateResponse(&response); err != nil {
-		s.logger.Error("Invalid response from API", err)
+		s.logger.ErrorContext(ctx, "Invalid response from API", err)
 		return nil, err
 	}
 
+	// The upstream accepted the job; the client set the key it used on the request
+	s.chargeKey(apiReq.Key, req)
+
 	// If the response is in processing state, poll for completion
 	if response.IsProcessing() {
 		if response.ID == 0 {
 			return nil, apperrors.NewExternalAPIError("Processing response missing ID", nil)
 		}
 
-		s.logger.Info("Request is processing, polling for completion",
+		s.logger.InfoContext(ctx, "Request is processing, polling for completion",
 			"id", response.ID,
 		)
-
-		finalResponse, err := s.pollForCompletion(ctx, response.ID)
+		// Only the key that created the job can poll or cancel it
+		ctx = models.WithUpstreamKey(ctx, s.keyName(apiReq.Key))
+		s.jobs.SetUpstreamID(generationID, response.ID, models.UpstreamKeyFromContext(ctx))
+		span.SetAttributes(attribute.Int64("upstream_id", response.ID))
+		s.trackPending(ctx, generationID, req, response.ID)
+		defer func() { s.releasePending(ctx, generationID, err) }()
+
+		finalResponse, err := s.pollForCompletion(ctx, req.ModelID, response.ID, response.ETA)
 		if err != nil {
-			s.logger.Error("Failed while polling for completion", err)
+			s.logger.ErrorContext(ctx, "Failed while polling for completion", err)
+			if jobs.IsShutdown(ctx) && s.pending != nil {
+				return nil, apperrors.NewUnavailableError(
+					fmt.Sprintf("Server shut down before generation %s finished; it will be available from /generations/%s after the restart", generationID, generationID),
+					err,
+				)
+			}
 			return nil, err
 		}
 		response = *finalResponse
 	}
 
-	s.logger.Info("Successfully generated image",
+	// Fall back to the request parameters when the upstream omits the meta block
+	if response.Meta == nil {
+		response.Meta = models.NewGenerationMeta(req)
+	}
+
+	response.GenerationID = generationID
+	s.recordGeneration(ctx, req, &response)
+
+	s.logger.InfoContext(ctx, "Successfully generated image",
+		"generation_id", response.GenerationID,
 		"generation_time", response.GenerationTime,
 		"image_count", len(response.Output),
+		"seed", response.Meta.Seed,
 	)
 
 	return &response, nil
 }
 
-// pollForCompletion polls the API until the image generation is complete or times out
-func (s *Service) pollForCompletion(ctx context.Context, id int64) (*models.Text2ImgResponse, error) {
-	maxAttempts := 10 // Reduced max attempts since we're using exponential backoff
-	attempt := 0
-	baseDelay := 1 * time.Second
+// keyName returns the pool's name for the upstream key, or an empty string without a pool
+func (s *Service) keyName(key string) string {
+	if s.keys == nil || key == "" {
+		return ""
+	}
+	return s.keys.Name(key)
+}
+
+// chargeKey deducts the request's estimated credits from the upstream key's budget
+func (s *Service) chargeKey(key string, req *models.Text2ImgRequest) {
+	if s.keys == nil || key == "" {
+		return
+	}
+
+	pricing, err := s.registry.Pricing(req.ModelID)
+	if err != nil {
+		return
+	}
+
+	s.keys.Charge(key, pricing.Estimate(req).Total)
+}
+
+// recordGeneration stores the generation so it can be looked up and reproduced later
+func (s *Service) recordGeneration(ctx context.Context, req *models.Text2ImgRequest, response *models.Text2ImgResponse) {
+	if s.generations == nil {
+		return
+	}
+
+	generation := &models.Generation{
+		ID:        response.GenerationID,
+		Request:   *req,
+		Response:  response,
+		CreatedAt: time.Now().UTC(),
+	}
+	generation.Request.Key = ""
+
+	if err := s.generations.Save(ctx, generation); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record generation", err,
+			"generation_id", generation.ID,
+		)
+	}
+}
+
+// ListJobs returns the generations that are currently in flight
+func (s *Service) ListJobs(ctx context.Context) []models.Job {
+	return s.jobs.List(ctx)
+}
+
+// CancelJob stops an in-flight generation by its job ID or upstream ID
+func (s *Service) CancelJob(ctx context.Context, id string) (*models.Job, error) {
+	job, err := s.jobs.Cancel(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoContext(ctx, "Generation cancelled",
+		"generation_id", job.ID,
+		"upstream_id", job.UpstreamID,
+	)
+
+	if job.UpstreamID != 0 && s.cancelEndpoint != "" {
+		s.cancelUpstream(models.WithUpstreamKey(ctx, job.UpstreamKey), job.UpstreamID)
+	}
+
+	return job, nil
+}
+
+// Drain stops accepting generations and waits for the in-flight ones until ctx is done.
+// Generations still running then are abandoned and logged; those already processing
+// upstream stay recorded as pending and are resumed on the next start.
+func (s *Service) Drain(ctx context.Context) {
+	if err := s.jobs.Drain(ctx); err == nil {
+		s.logger.Info("All in-flight generations finished")
+		return
+	}
+
+	for _, job := range s.jobs.Abandon() {
+		s.logger.Info("Abandoned in-flight generation",
+			"generation_id", job.ID,
+			"tenant_id", job.TenantID,
+			"model_id", job.ModelID,
+			"upstream_id", job.UpstreamID,
+			"started_at", job.StartedAt,
+			"resumable", job.UpstreamID != 0 && s.pending != nil,
+		)
+	}
+}
+
+// ResumePending resumes polling the jobs that were still processing upstream when the
+// server last stopped. Each is polled in the background as a tracked generation, so it
+// can be listed, cancelled and drained, and its result is recorded in the history and
+// passed to the recorders for billing and auditing on behalf of the original caller.
+func (s *Service) ResumePending(ctx context.Context, recorders ...ports.ResumedGenerationRecorder) {
+	if s.pending == nil {
+		return
+	}
+
+	pending, err := s.pending.List(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load pending upstream jobs", err)
+		return
+	}
+
+	for _, job := range pending {
+		s.logger.Info("Resuming upstream job",
+			"generation_id", job.GenerationID,
+			"tenant_id", job.TenantID,
+			"model_id", job.ModelID,
+			"upstream_id", job.UpstreamID,
+		)
+		go s.resume(ctx, job, recorders)
+	}
+}
+
+// resume polls a pending job until it completes and records the resulting generation
+func (s *Service) resume(ctx context.Context, job *models.PendingJob, recorders []ports.ResumedGenerationRecorder) {
+	ctx = models.WithTenant(ctx, job.TenantID)
+	ctx = models.WithPrincipal(ctx, job.Principal())
+	if job.RequestID != "" {
+		ctx = models.WithRequestID(ctx, job.RequestID)
+	}
+
+	ctx, span := tracer.Start(ctx, "modelslab.Service.Resume", trace.WithAttributes(
+		attribute.String("generation_id", job.GenerationID),
+		attribute.String("model_id", job.ModelID),
+		attribute.Int64("upstream_id", job.UpstreamID),
+	))
+	defer span.End()
+
+	ctx, err := s.jobs.Start(ctx, job.GenerationID, job.ModelID)
+	if err != nil {
+		// The server is already stopping; the job stays pending for the next start
+		return
+	}
+	defer s.jobs.Finish(job.GenerationID)
+	ctx = models.WithUpstreamKey(ctx, job.UpstreamKey)
+	s.jobs.SetUpstreamID(job.GenerationID, job.UpstreamID, job.UpstreamKey)
+	defer func() { s.releasePending(ctx, job.GenerationID, err) }()
+
+	if s.metrics != nil {
+		s.metrics.GenerationStarted(job.ModelID)
+		defer s.metrics.GenerationFinished(job.ModelID)
+	}
+
+	response, err := s.pollForCompletion(ctx, job.ModelID, job.UpstreamID, 0)
+	if err != nil {
+		tracing.RecordError(span, err)
+		s.logger.ErrorContext(ctx, "Failed to resume upstream job", err,
+			"generation_id", job.GenerationID,
+			"upstream_id", job.UpstreamID,
+		)
+		return
+	}
+
+	req := job.Request
+	if response.Meta == nil {
+		response.Meta = models.NewGenerationMeta(&req)
+	}
+	response.GenerationID = job.GenerationID
+	s.recordGeneration(ctx, &req, response)
+	for _, recorder := range recorders {
+		recorder.RecordResumed(ctx, &req, response)
+	}
+
+	s.logger.InfoContext(ctx, "Recovered generation from resumed upstream job",
+		"generation_id", job.GenerationID,
+		"upstream_id", job.UpstreamID,
+		"image_count", len(response.Output),
+	)
+}
+
+// trackPending records a job processing upstream so it can be resumed after a restart
+func (s *Service) trackPending(ctx context.Context, generationID string, req *models.Text2ImgRequest, upstreamID int64) {
+	if s.pending == nil {
+		return
+	}
+
+	principal := models.PrincipalOrAnonymous(ctx)
+	job := &models.PendingJob{
+		GenerationID:  generationID,
+		TenantID:      models.TenantFromContext(ctx),
+		PrincipalID:   principal.ID,
+		PrincipalName: principal.Name,
+		RequestID:     models.RequestIDFromContext(ctx),
+		ModelID:       req.ModelID,
+		UpstreamID:    upstreamID,
+		UpstreamKey:   models.UpstreamKeyFromContext(ctx),
+		Request:       *req,
+		StartedAt:     time.Now().UTC(),
+	}
+	job.Request.Key = ""
+
+	if err := s.pending.Save(ctx, job); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to record pending upstream job", err,
+			"generation_id", generationID,
+			"upstream_id", upstreamID,
+		)
+	}
+}
+
+// releasePending forgets a pending job once its outcome is known. Jobs abandoned by a
+// shutdown, or by a request that timed out or went away while they were processing, are
+// kept so they are resumed on the next start.
+func (s *Service) releasePending(ctx context.Context, generationID string, err error) {
+	if s.pending == nil || jobs.IsShutdown(ctx) {
+		return
+	}
+	if err != nil && ctx.Err() != nil && !jobs.IsCancelled(ctx) {
+		s.logger.InfoContext(ctx, "Request ended before its upstream job finished, keeping it to resume",
+			"generation_id", generationID,
+		)
+		return
+	}
+
+	if err := s.pending.Delete(ctx, generationID); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to release pending upstream job", err,
+			"generation_id", generationID,
+		)
+	}
+}
+
+// cancelUpstream asks ModelsLab to stop processing a job, with the key pinned in ctx; failures
+// are logged and otherwise ignored since the local generation has already been stopped
+func (s *Service) cancelUpstream(ctx context.Context, upstreamID int64) {
+	endpoint := strings.ReplaceAll(s.cancelEndpoint, "{id}", strconv.FormatInt(upstreamID, 10))
+
+	var response models.Text2ImgResponse
+	if err := s.client.Post(ctx, endpoint, &models.ModelsLabKeyRequest{}, &response); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to cancel upstream job", err,
+			"upstream_id", upstreamID,
+		)
+		return
+	}
+
+	if response.Status == "error" {
+		s.logger.InfoContext(ctx, "Upstream refused to cancel job",
+			"upstream_id", upstreamID,
+			"message", response.Message,
+		)
+	}
+}
+
+// pollForCompletion polls the API until the image generation is complete or the polling
+// deadline passes, recording the attempts and time taken for the model
+func (s *Service) pollForCompletion(ctx context.Context, modelID string, id int64, eta float64) (*models.Text2ImgResponse, error) {
+	start := time.Now()
+	response, attempts, err := s.poll(ctx, id, eta)
+
+	if s.metrics != nil {
+		s.metrics.ObservePolling(modelID, attempts, time.Since(start), pollOutcome(err))
+	}
+
+	return response, err
+}
+
+// pollOutcome labels how a polling loop ended
+func pollOutcome(err error) string {
+	if err == nil {
+		return "success"
+	}
+
+	switch apperrors.FromError(err).Code {
+	case apperrors.ErrTimeout:
+		return "timeout"
+	case apperrors.ErrCancelled:
+		return "cancelled"
+	case apperrors.ErrUnavailable:
+		return "abandoned"
+	default:
+		return "error"
+	}
+}
+
+// poll checks the job's status with backoff until it completes, returning the number of polls made
+func (s *Service) poll(ctx context.Context, id int64, eta float64) (*models.Text2ImgResponse, int, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, s.polling.MaxWait)
+	defer cancel()
+
+	for attempt := 0; ; attempt++ {
+		delay := s.polling.NextDelay(attempt, eta)
+		timer := time.NewTimer(delay)
 
-	for {
 		select {
-		case <-ctx.Done():
-			return nil, apperrors.NewExternalAPIError("Request cancelled", ctx.Err())
-		case <-time.After(time.Duration(float64(baseDelay) * math.Pow(1.5, float64(attempt)))):
-			attempt++
-			if attempt > maxAttempts {
-				return nil, apperrors.NewExternalAPIError(
-					"Image generation timed out",
-					fmt.Errorf("exceeded maximum polling attempts (%d)", maxAttempts),
-				)
-			}
+		case <-pollCtx.Done():
+			timer.Stop()
+			return nil, attempt, s.pollingStopped(ctx, id, attempt)
+		case <-timer.C:
+		}
 
-			// Try different status endpoints in order of likelihood
-			endpoints := []string{
-				fmt.Sprintf("/status/%d", id),
-				fmt.Sprintf("/images/status/%d", id),
-				fmt.Sprintf("/images/text2img/%d", id),
-			}
+		response, endpoint, err := s.pollOnce(pollCtx, id, attempt+1)
+		if err != nil {
+			s.logger.DebugContext(ctx, "All status endpoints failed, retrying with backoff",
+				"attempt", attempt+1,
+				"error", err,
+			)
+			continue
+		}
 
-			var lastErr error
-			for _, endpoint := range endpoints {
-				var response models.Text2ImgResponse
-				if err := s.client.Get(ctx, endpoint, &response); err != nil {
-					lastErr = err
-					s.logger.Debug("Status check failed",
-						"endpoint", endpoint,
-						"error", err,
-					)
-					continue
-				}
-
-				// Log progress
-				s.logger.Debug("Polling status",
-					"attempt", attempt,
-					"endpoint", endpoint,
-					"status", response.Status,
-					"progress", response.Progress,
-				)
+		// Log progress
+		s.logger.DebugContext(ctx, "Polling status",
+			"attempt", attempt+1,
+			"endpoint", endpoint,
+			"status", response.Status,
+			"progress", response.Progress,
+			"eta", response.ETA,
+		)
 
-				// Check if complete
-				if response.IsSuccess() {
-					return &response, nil
-				}
+		// Check if complete
+		if response.IsSuccess() {
+			return response, attempt + 1, nil
+		}
 
-				// Continue polling if still processing
-				if response.IsProcessing() {
-					break // Found working endpoint, use it for next attempt
-				}
+		// Continue polling if still processing, following the latest ETA
+		if response.IsProcessing() {
+			eta = response.ETA
+			continue
+		}
 
-				// Unexpected status
-				return nil, apperrors.NewExternalAPIError(
-					"Unexpected status during polling",
-					fmt.Errorf("status: %s", response.Status),
-				)
-			}
+		// Unexpected status
+		return nil, attempt + 1, apperrors.NewExternalAPIError(
+			"Unexpected status during polling",
+			fmt.Errorf("status: %s", response.Status),
+		)
+	}
+}
 
-			// All endpoints failed
-			if lastErr != nil {
-				if attempt == maxAttempts {
-					return nil, fmt.Errorf("failed to poll status: %w", lastErr)
-				}
-				s.logger.Debug("All status endpoints failed, retrying with backoff",
-					"attempt", attempt,
-					"next_delay", time.Duration(float64(baseDelay)*math.Pow(1.5, float64(attempt))),
-				)
-			}
+// pollingStopped explains why polling ended before the job completed
+func (s *Service) pollingStopped(ctx context.Context, id int64, attempts int) error {
+	switch {
+	case jobs.IsCancelled(ctx):
+		return apperrors.NewCancelledError("Generation cancelled", context.Cause(ctx))
+	case jobs.IsShutdown(ctx):
+		return apperrors.NewUnavailableError("Server shut down before the generation finished", context.Cause(ctx))
+	case ctx.Err() != nil:
+		return apperrors.NewExternalAPIError("Request cancelled", ctx.Err())
+	default:
+		return apperrors.NewTimeoutError(
+			"Image generation timed out",
+			fmt.Errorf("job %d not complete after %s (%d polls)", id, s.polling.MaxWait, attempts),
+		)
+	}
+}
+
+// pollOnce checks the job's status within a span for the poll iteration
+func (s *Service) pollOnce(ctx context.Context, id int64, attempt int) (*models.Text2ImgResponse, string, error) {
+	ctx, span := tracer.Start(ctx, "modelslab.poll", trace.WithAttributes(
+		attribute.Int64("upstream_id", id),
+		attribute.Int("attempt", attempt),
+	))
+	defer span.End()
+
+	response, endpoint, err := s.checkStatus(ctx, id)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, "", err
+	}
+
+	span.SetAttributes(
+		attribute.String("endpoint", endpoint),
+		attribute.String("status", response.Status),
+	)
+	return response, endpoint, nil
+}
+
+// checkStatus queries the status endpoints for a job, starting with the one that last answered
+func (s *Service) checkStatus(ctx context.Context, id int64) (*models.Text2ImgResponse, string, error) {
+	endpoints := s.polling.Endpoints
+	preferred := int(s.statusEndpoint.Load())
+
+	order := make([]int, 0, len(endpoints))
+	order = append(order, preferred)
+	for i := range endpoints {
+		if i != preferred {
+			order = append(order, i)
 		}
 	}
+
+	var lastErr error
+	for _, i := range order {
+		endpoint := strings.ReplaceAll(endpoints[i], "{id}", strconv.FormatInt(id, 10))
+
+		var response models.Text2ImgResponse
+		if err := s.client.Get(ctx, endpoint, &response); err != nil {
+			lastErr = err
+			s.logger.DebugContext(ctx, "Status check failed",
+				"endpoint", endpoint,
+				"error", err,
+			)
+			continue
+		}
+
+		// An empty body means the endpoint does not know about the job
+		if response.Status == "" {
+			lastErr = fmt.Errorf("empty status from %s", endpoint)
+			continue
+		}
+
+		if i != preferred {
+			s.statusEndpoint.Store(int32(i))
+			s.logger.InfoContext(ctx, "Switching to working status endpoint",
+				"endpoint", endpoints[i],
+			)
+		}
+
+		return &response, endpoint, nil
+	}
+
+	return nil, "", fmt.Errorf("failed to poll status: %w", lastErr)
 }
 
 // validateRequest performs validation on the request
@@ -243,7 +807,7 @@ func (s *Service) validateRequest(req *models.Text2ImgRequest) error {
 	}
 
 	// Validate dimensions
-	if req.Width*req.Height > 1024*1024 {
+	if req.Width*req.Height > s.maxPixels {
 		return apperrors.NewInvalidRequestError(
 			"Image dimensions exceed maximum allowed size",
 			nil,
@@ -251,9 +815,9 @@ func (s *Service) validateRequest(req *models.Text2ImgRequest) error {
 	}
 
 	// Validate samples
-	if req.Samples > 4 {
+	if req.Samples > s.maxSamples {
 		return apperrors.NewInvalidRequestError(
-			"Maximum number of samples exceeded (max: 4)",
+			fmt.Sprintf("Maximum number of samples exceeded (max: %d)", s.maxSamples),
 			nil,
 		)
 	}
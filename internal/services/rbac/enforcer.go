@@ -0,0 +1,113 @@
+package rbac
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// Enforcer implements the AccessPolicy interface from per-role policies. A principal may do
+// whatever any of its roles' policies allows. Principals none of whose roles has a policy
+// get the default role's policy, and are denied everything when there is none. Admins and
+// unauthenticated requests are never restricted, nor is anyone when no policy is configured.
+type Enforcer struct {
+	policies map[string]models.RolePolicy
+	logger   ports.Logger
+}
+
+// NewEnforcer creates a new enforcer for the policies keyed by role
+func NewEnforcer(policies map[string]models.RolePolicy, logger ports.Logger) *Enforcer {
+	return &Enforcer{
+		policies: policies,
+		logger:   logger,
+	}
+}
+
+// AllowsModel reports whether the caller may use the model
+func (e *Enforcer) AllowsModel(ctx context.Context, modelID string) bool {
+	policies, restricted := e.policiesFor(ctx)
+	if !restricted {
+		return true
+	}
+
+	for _, policy := range policies {
+		if policy.AllowsModel(modelID) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsEndpoint reports whether the caller may call the route
+func (e *Enforcer) AllowsEndpoint(ctx context.Context, route string) bool {
+	policies, restricted := e.policiesFor(ctx)
+	if !restricted {
+		return true
+	}
+
+	for _, policy := range policies {
+		if policy.AllowsEndpoint(route) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns a forbidden error when none of the caller's policies allows the request
+func (e *Enforcer) Authorize(ctx context.Context, req *models.Text2ImgRequest) error {
+	policies, restricted := e.policiesFor(ctx)
+	if !restricted {
+		return nil
+	}
+
+	var denied error
+	for _, policy := range policies {
+		err := policy.Check(req)
+		if err == nil {
+			return nil
+		}
+		if denied == nil {
+			denied = err
+		}
+	}
+	if denied == nil {
+		denied = apperrors.NewForbiddenError("None of your roles grants access to image generation", nil)
+	}
+
+	principal, _ := models.PrincipalFromContext(ctx)
+	e.logger.InfoContext(ctx, "Request denied by role policy",
+		"principal_id", principal.ID,
+		"roles", principal.Roles,
+		"model_id", req.ModelID,
+	)
+
+	return denied
+}
+
+// policiesFor returns the policies that apply to the caller, and false when the caller is
+// unrestricted. A restricted caller with no policies may do nothing.
+func (e *Enforcer) policiesFor(ctx context.Context) ([]models.RolePolicy, bool) {
+	principal, ok := models.PrincipalFromContext(ctx)
+	if !ok || len(e.policies) == 0 || principal.HasScope(models.ScopeAdmin) {
+		return nil, false
+	}
+
+	var policies []models.RolePolicy
+	for _, role := range principal.Roles {
+		if policy, ok := e.policies[role]; ok {
+			policies = append(policies, policy)
+		}
+	}
+
+	if len(policies) == 0 {
+		// A principal without a known role, e.g. after a typo or a removed policy, gets
+		// nothing rather than everything
+		if policy, ok := e.policies[models.DefaultRole]; ok {
+			policies = append(policies, policy)
+		}
+	}
+
+	return policies, true
+}
@@ -0,0 +1,141 @@
+package sweep
+
+import (
+	"context"
+	"fmt"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// Service implements the SweepService interface
+type Service struct {
+	batch     ports.BatchService
+	registry  ports.ModelRegistry
+	validator ports.Validator
+	logger    ports.Logger
+	maxCells  int
+	access    ports.AccessPolicy
+}
+
+// ServiceOption defines a function type for service configuration
+type ServiceOption func(*Service)
+
+// NewService creates a new parameter sweep service instance
+func NewService(batch ports.BatchService, registry ports.ModelRegistry, validator ports.Validator, logger ports.Logger, opts ...ServiceOption) *Service {
+	s := &Service{
+		batch:     batch,
+		registry:  registry,
+		validator: validator,
+		logger:    logger,
+		maxCells:  64,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithMaxCells sets the maximum number of cells accepted in one grid
+func WithMaxCells(maxCells int) ServiceOption {
+	return func(s *Service) {
+		s.maxCells = maxCells
+	}
+}
+
+// WithAccessPolicy rejects grids containing cells the caller's roles may not generate
+func WithAccessPolicy(access ports.AccessPolicy) ServiceOption {
+	return func(s *Service) {
+		s.access = access
+	}
+}
+
+// Generate expands the sweep axes, validates every cell and generates the grid
+func (s *Service) Generate(ctx context.Context, req *models.SweepRequest) (*models.SweepResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if cells := req.CellCount(); cells > s.maxCells {
+		return nil, apperrors.NewInvalidRequestError(
+			fmt.Sprintf("Sweep expands to %d cells, maximum is %d", cells, s.maxCells),
+			nil,
+		)
+	}
+
+	params, requests, err := req.Expand()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reject the whole grid up front rather than paying for a partial one
+	for i := range requests {
+		if err := s.validateCell(ctx, &requests[i]); err != nil {
+			appErr := apperrors.FromError(err)
+			return nil, apperrors.NewInvalidRequestError(
+				fmt.Sprintf("Cell %d is invalid: %s", i, appErr.Message),
+				err,
+			)
+		}
+	}
+
+	s.logger.InfoContext(ctx, "Processing sweep request",
+		"model_id", req.Base.ModelID,
+		"axes", len(req.Axes),
+		"cells", len(requests),
+	)
+
+	result := s.batch.GenerateItems(ctx, requests)
+
+	response := &models.SweepResponse{
+		Status:    result.Status,
+		Axes:      req.Axes,
+		Total:     result.Total,
+		Succeeded: result.Succeeded,
+		Failed:    result.Failed,
+		Cells:     make([]models.SweepCell, len(result.Items)),
+	}
+
+	for i, item := range result.Items {
+		response.Cells[i] = models.SweepCell{
+			Index:          i,
+			Parameters:     params[i],
+			Status:         item.Status,
+			Output:         item.Output,
+			GenerationTime: item.GenerationTime,
+			ID:             item.ID,
+			Error:          item.Error,
+		}
+	}
+
+	return response, nil
+}
+
+// validateCell checks a single expanded request against the schema and the capabilities
+// of a model the tenant may use and the caller's role policies, after filling in the tenant's defaults
+func (s *Service) validateCell(ctx context.Context, req *models.Text2ImgRequest) error {
+	tenantID := models.TenantFromContext(ctx)
+	s.registry.ApplyDefaults(tenantID, req)
+
+	if err := s.validator.Validate(req); err != nil {
+		return err
+	}
+
+	model, err := s.registry.GetForTenant(tenantID, req.ModelID)
+	if err != nil {
+		return err
+	}
+
+	if err := model.ValidateRequest(req); err != nil {
+		return err
+	}
+
+	if s.access != nil {
+		return s.access.Authorize(ctx, req)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package upstreamkeys
+
+import (
+	"context"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+)
+
+// auditTarget names the key pool in audit events
+const auditTarget = "modelslab_keys"
+
+// Service implements the UpstreamKeyService interface
+type Service struct {
+	pool   ports.UpstreamKeyPool
+	audit  ports.AuditLog
+	logger ports.Logger
+}
+
+// NewService creates a new upstream key service instance
+func NewService(pool ports.UpstreamKeyPool, audit ports.AuditLog, logger ports.Logger) *Service {
+	return &Service{
+		pool:   pool,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+// Status reports the pool's strategy and the health of every key
+func (s *Service) Status(ctx context.Context) *models.UpstreamKeysResponse {
+	return &models.UpstreamKeysResponse{
+		Strategy: s.pool.Strategy(),
+		Keys:     s.pool.Status(),
+	}
+}
+
+// Reload reloads the keys without a restart. A failed reload keeps the current keys.
+func (s *Service) Reload(ctx context.Context) (*models.UpstreamKeysResponse, error) {
+	err := s.pool.Reload()
+	s.audit.Record(ctx, models.AuditConfigReload, auditTarget, err)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to reload ModelsLab API keys", err)
+		return nil, apperrors.NewInvalidRequestError("Failed to reload ModelsLab API keys", err)
+	}
+
+	return s.Status(ctx), nil
+}
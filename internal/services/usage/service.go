@@ -0,0 +1,387 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"image/internal/domain/models"
+	"image/internal/domain/ports"
+	apperrors "image/pkg/errors"
+	"image/pkg/ids"
+)
+
+// Service meters generations per principal and enforces image quotas. It implements the
+// ModelsLabService interface by wrapping the real generator, and the UsageService interface.
+type Service struct {
+	generator    ports.ModelsLabService
+	repository   ports.UsageRepository
+	logger       ports.Logger
+	registry     ports.ModelRegistry
+	defaultQuota models.Quota
+	quotas       map[string]models.Quota
+	// tenantQuotas limit the images all principals of a tenant generate together
+	tenantQuotas map[string]models.Quota
+
+	// reserved counts images of in-flight generations per quota so concurrent
+	// requests cannot overrun it together
+	reserved map[string]int
+	// counters keep the images recorded against each quota in its current day and month,
+	// so checking a quota does not scan the usage history
+	counters map[string]*usageCounter
+	mu       sync.Mutex
+}
+
+// usageCounter is the running image count of one quota
+type usageCounter struct {
+	day     time.Time
+	month   time.Time
+	daily   int
+	monthly int
+}
+
+// roll resets the counts whose window has ended by now
+func (c *usageCounter) roll(now time.Time) {
+	if month := startOfMonth(now); !month.Equal(c.month) {
+		c.month = month
+		c.monthly = 0
+	}
+	if day := startOfDay(now); !day.Equal(c.day) {
+		c.day = day
+		c.daily = 0
+	}
+}
+
+// ServiceOption defines a function type for service configuration
+type ServiceOption func(*Service)
+
+// NewService creates a new usage service wrapping the generator
+func NewService(generator ports.ModelsLabService, repository ports.UsageRepository, logger ports.Logger, opts ...ServiceOption) *Service {
+	s := &Service{
+		generator:    generator,
+		repository:   repository,
+		logger:       logger,
+		quotas:       make(map[string]models.Quota),
+		tenantQuotas: make(map[string]models.Quota),
+		reserved:     make(map[string]int),
+		counters:     make(map[string]*usageCounter),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// WithDefaultQuota sets the quota applied to principals without an override
+func WithDefaultQuota(quota models.Quota) ServiceOption {
+	return func(s *Service) {
+		s.defaultQuota = quota
+	}
+}
+
+// WithQuotaOverrides sets quotas for specific principals, keyed by tenant ID and principal ID
+// joined with a slash
+func WithQuotaOverrides(quotas map[string]models.Quota) ServiceOption {
+	return func(s *Service) {
+		for id, quota := range quotas {
+			s.quotas[id] = quota
+		}
+	}
+}
+
+// WithModelRegistry fills in the tenant's request defaults before billing, so parameters
+// left unset are billed as they will be generated
+func WithModelRegistry(registry ports.ModelRegistry) ServiceOption {
+	return func(s *Service) {
+		s.registry = registry
+	}
+}
+
+// WithTenantQuotas sets quotas shared by all principals of specific tenants
+func WithTenantQuotas(quotas map[string]models.Quota) ServiceOption {
+	return func(s *Service) {
+		for id, quota := range quotas {
+			s.tenantQuotas[id] = quota
+		}
+	}
+}
+
+// quotaLimit is one quota a generation must fit in
+type quotaLimit struct {
+	// key identifies the quota's reservations
+	key string
+	// principalID restricts the usage counted against the quota; empty counts the whole tenant
+	principalID string
+	// scope qualifies the quota in error messages, e.g. "organization "
+	scope string
+	quota models.Quota
+}
+
+// GenerateImage checks the caller's quota, generates the image and records the usage
+func (s *Service) GenerateImage(ctx context.Context, req *models.Text2ImgRequest) (*models.Text2ImgResponse, error) {
+	principal := models.PrincipalOrAnonymous(ctx)
+	if s.registry != nil {
+		s.registry.ApplyDefaults(models.TenantFromContext(ctx), req)
+	}
+	images := req.BilledImages()
+
+	limits := s.limitsFor(models.TenantFromContext(ctx), principal.ID)
+	if err := s.reserve(ctx, limits, images); err != nil {
+		s.logger.InfoContext(ctx, "Quota exceeded",
+			"principal_id", principal.ID,
+			"images", images,
+		)
+		return nil, err
+	}
+	defer s.release(limits, images)
+
+	resp, err := s.generator.GenerateImage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.record(ctx, req, resp)
+	return resp, nil
+}
+
+// RecordResumed records the usage of a generation that completed after a restart. Its
+// images were not reserved, since the original request already ended, but are billed
+// and counted against the caller's quotas all the same.
+func (s *Service) RecordResumed(ctx context.Context, req *models.Text2ImgRequest, resp *models.Text2ImgResponse) {
+	s.record(ctx, req, resp)
+}
+
+// record writes the usage record of a completed generation for the caller in ctx
+func (s *Service) record(ctx context.Context, req *models.Text2ImgRequest, resp *models.Text2ImgResponse) {
+	principal := models.PrincipalOrAnonymous(ctx)
+	tenantID := models.TenantFromContext(ctx)
+	images := req.BilledImages()
+
+	record := &models.UsageRecord{
+		ID:             ids.New("use"),
+		PrincipalID:    principal.ID,
+		PrincipalName:  principal.Name,
+		ModelID:        req.ModelID,
+		GenerationID:   resp.GenerationID,
+		Images:         images,
+		Steps:          req.NumInferenceSteps,
+		GenerationTime: resp.GenerationTime,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	// Counters are built from the repository, so recording and counting happen together
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repository.Record(ctx, record); err != nil {
+		// The images were generated and paid for; losing the record is logged, not surfaced
+		s.logger.ErrorContext(ctx, "Failed to record usage", err,
+			"principal_id", principal.ID,
+			"generation_id", resp.GenerationID,
+		)
+		return
+	}
+
+	// Counters not built yet will count the record when they are
+	for _, key := range []string{quotaKey(tenantID, principal.ID), tenantID} {
+		if counter, ok := s.counters[key]; ok {
+			counter.roll(record.CreatedAt)
+			counter.daily += images
+			counter.monthly += images
+		}
+	}
+}
+
+// Report aggregates usage per principal over a period
+func (s *Service) Report(ctx context.Context, filter models.UsageFilter) (*models.UsageReport, error) {
+	records, err := s.repository.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*models.UsageSummary)
+	for _, record := range records {
+		summary, ok := summaries[record.PrincipalID]
+		if !ok {
+			summary = &models.UsageSummary{
+				PrincipalID:   record.PrincipalID,
+				PrincipalName: record.PrincipalName,
+			}
+			summaries[record.PrincipalID] = summary
+		}
+		summary.Generations++
+		summary.Images += record.Images
+		summary.Steps += record.Steps
+		summary.GenerationTime += record.GenerationTime
+	}
+
+	report := &models.UsageReport{
+		From:       filter.From,
+		To:         filter.To,
+		Principals: make([]models.UsageSummary, 0, len(summaries)),
+	}
+	for _, summary := range summaries {
+		report.Principals = append(report.Principals, *summary)
+	}
+	sort.Slice(report.Principals, func(i, j int) bool {
+		return report.Principals[i].PrincipalID < report.Principals[j].PrincipalID
+	})
+
+	return report, nil
+}
+
+// Records returns the raw usage records matching the filter
+func (s *Service) Records(ctx context.Context, filter models.UsageFilter) ([]*models.UsageRecord, error) {
+	return s.repository.List(ctx, filter)
+}
+
+// QuotaStatus returns a principal's consumption against its quota
+func (s *Service) QuotaStatus(ctx context.Context, principalID string) (*models.QuotaStatus, error) {
+	s.mu.Lock()
+	usedToday, usedThisMonth, err := s.used(ctx, quotaKey(models.TenantFromContext(ctx), principalID), principalID, time.Now().UTC())
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QuotaStatus{
+		Quota:         s.quotaFor(models.TenantFromContext(ctx), principalID),
+		UsedToday:     usedToday,
+		UsedThisMonth: usedThisMonth,
+	}, nil
+}
+
+// limitsFor returns the quotas a principal's generations count against
+func (s *Service) limitsFor(tenantID, principalID string) []quotaLimit {
+	var limits []quotaLimit
+
+	if quota := s.quotaFor(tenantID, principalID); !quota.IsUnlimited() {
+		limits = append(limits, quotaLimit{
+			key:         quotaKey(tenantID, principalID),
+			principalID: principalID,
+			quota:       quota,
+		})
+	}
+
+	if quota, ok := s.tenantQuotas[tenantID]; ok && !quota.IsUnlimited() {
+		limits = append(limits, quotaLimit{
+			key:   tenantID,
+			scope: "organization ",
+			quota: quota,
+		})
+	}
+
+	return limits
+}
+
+// reserve checks that the images fit in the remaining quotas and holds them
+// until the generation finishes
+func (s *Service) reserve(ctx context.Context, limits []quotaLimit, images int) error {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, limit := range limits {
+		usedToday, usedThisMonth, err := s.used(ctx, limit.key, limit.principalID, now)
+		if err != nil {
+			return err
+		}
+		reserved := s.reserved[limit.key]
+
+		if limit.quota.DailyImages > 0 && usedToday+reserved+images > limit.quota.DailyImages {
+			return apperrors.NewQuotaExceededError(
+				fmt.Sprintf("Daily %simage quota of %d exceeded", limit.scope, limit.quota.DailyImages),
+				startOfDay(now).AddDate(0, 0, 1).Sub(now),
+			)
+		}
+
+		if limit.quota.MonthlyImages > 0 && usedThisMonth+reserved+images > limit.quota.MonthlyImages {
+			return apperrors.NewQuotaExceededError(
+				fmt.Sprintf("Monthly %simage quota of %d exceeded", limit.scope, limit.quota.MonthlyImages),
+				startOfMonth(now).AddDate(0, 1, 0).Sub(now),
+			)
+		}
+	}
+
+	for _, limit := range limits {
+		s.reserved[limit.key] += images
+	}
+	return nil
+}
+
+// release returns images held by reserve
+func (s *Service) release(limits []quotaLimit, images int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, limit := range limits {
+		if _, ok := s.reserved[limit.key]; !ok {
+			continue
+		}
+
+		s.reserved[limit.key] -= images
+		if s.reserved[limit.key] <= 0 {
+			delete(s.reserved, limit.key)
+		}
+	}
+}
+
+// used returns the images counted against the quota with the given key today and this
+// month. A quota's counter is built from the usage history of the principal, or of the
+// whole tenant when principalID is empty, the first time it is needed. The caller must
+// hold s.mu.
+func (s *Service) used(ctx context.Context, key, principalID string, now time.Time) (int, int, error) {
+	counter, ok := s.counters[key]
+	if !ok {
+		records, err := s.repository.List(ctx, models.UsageFilter{
+			PrincipalID: principalID,
+			From:        startOfMonth(now),
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+
+		counter = &usageCounter{day: startOfDay(now), month: startOfMonth(now)}
+		for _, record := range records {
+			counter.monthly += record.Images
+			if !record.CreatedAt.Before(counter.day) {
+				counter.daily += record.Images
+			}
+		}
+		s.counters[key] = counter
+	}
+
+	counter.roll(now)
+	return counter.daily, counter.monthly, nil
+}
+
+// quotaKey identifies a principal's quota within its tenant
+func quotaKey(tenantID, principalID string) string {
+	return tenantID + "/" + principalID
+}
+
+// quotaFor returns the quota that applies to a principal of a tenant
+func (s *Service) quotaFor(tenantID, principalID string) models.Quota {
+	if quota, ok := s.quotas[quotaKey(tenantID, principalID)]; ok {
+		return quota
+	}
+	return s.defaultQuota
+}
+
+// startOfDay returns midnight UTC of t's day
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// startOfMonth returns midnight UTC of the first day of t's month
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -1,8 +1,10 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // ErrorCode represents a specific error type
@@ -13,12 +15,28 @@ const (
 	ErrInvalidRequest ErrorCode = "INVALID_REQUEST"
 	// ErrUnauthorized represents authentication errors
 	ErrUnauthorized ErrorCode = "UNAUTHORIZED"
+	// ErrForbidden represents authorization errors
+	ErrForbidden ErrorCode = "FORBIDDEN"
 	// ErrInternalServer represents internal server errors
 	ErrInternalServer ErrorCode = "INTERNAL_SERVER_ERROR"
 	// ErrExternalAPI represents errors from external API calls
 	ErrExternalAPI ErrorCode = "EXTERNAL_API_ERROR"
 	// ErrTimeout represents timeout errors
 	ErrTimeout ErrorCode = "TIMEOUT"
+	// ErrNotFound represents errors for resources that do not exist
+	ErrNotFound ErrorCode = "NOT_FOUND"
+	// ErrCancelled represents generations cancelled through the API
+	ErrCancelled ErrorCode = "CANCELLED"
+	// ErrRateLimited represents requests rejected by rate limiting
+	ErrRateLimited ErrorCode = "RATE_LIMITED"
+	// ErrQuotaExceeded represents requests rejected because a usage quota is exhausted
+	ErrQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
+	// ErrUnavailable represents requests refused because the server is shutting down
+	ErrUnavailable ErrorCode = "UNAVAILABLE"
+	// ErrPayloadTooLarge represents request bodies over the route's size limit
+	ErrPayloadTooLarge ErrorCode = "PAYLOAD_TOO_LARGE"
+	// ErrUnsupportedMediaType represents request bodies in a format the API does not accept
+	ErrUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
 )
 
 // AppError represents an application-specific error
@@ -27,6 +45,8 @@ type AppError struct {
 	Message string
 	Err     error
 	Status  int
+	// RetryAfter tells the client how long to wait before retrying, when known
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface
@@ -62,6 +82,16 @@ func NewUnauthorizedError(message string, err error) *AppError {
 	}
 }
 
+// NewForbiddenError creates a new forbidden error
+func NewForbiddenError(message string, err error) *AppError {
+	return &AppError{
+		Code:    ErrForbidden,
+		Message: message,
+		Err:     err,
+		Status:  http.StatusForbidden,
+	}
+}
+
 // NewInternalServerError creates a new internal server error
 func NewInternalServerError(message string, err error) *AppError {
 	return &AppError{
@@ -91,3 +121,82 @@ func NewTimeoutError(message string, err error) *AppError {
 		Status:  http.StatusGatewayTimeout,
 	}
 }
+
+// NewNotFoundError creates a new not found error
+func NewNotFoundError(message string, err error) *AppError {
+	return &AppError{
+		Code:    ErrNotFound,
+		Message: message,
+		Err:     err,
+		Status:  http.StatusNotFound,
+	}
+}
+
+// NewCancelledError creates a new cancelled error
+func NewCancelledError(message string, err error) *AppError {
+	return &AppError{
+		Code:    ErrCancelled,
+		Message: message,
+		Err:     err,
+		Status:  http.StatusConflict,
+	}
+}
+
+// NewRateLimitedError creates a new rate limited error
+func NewRateLimitedError(message string, retryAfter time.Duration) *AppError {
+	return &AppError{
+		Code:       ErrRateLimited,
+		Message:    message,
+		Status:     http.StatusTooManyRequests,
+		RetryAfter: retryAfter,
+	}
+}
+
+// NewQuotaExceededError creates a new quota exceeded error
+func NewQuotaExceededError(message string, retryAfter time.Duration) *AppError {
+	return &AppError{
+		Code:       ErrQuotaExceeded,
+		Message:    message,
+		Status:     http.StatusTooManyRequests,
+		RetryAfter: retryAfter,
+	}
+}
+
+// NewUnavailableError creates a new unavailable error
+func NewUnavailableError(message string, err error) *AppError {
+	return &AppError{
+		Code:    ErrUnavailable,
+		Message: message,
+		Err:     err,
+		Status:  http.StatusServiceUnavailable,
+	}
+}
+
+// NewPayloadTooLargeError creates a new payload too large error
+func NewPayloadTooLargeError(message string, err error) *AppError {
+	return &AppError{
+		Code:    ErrPayloadTooLarge,
+		Message: message,
+		Err:     err,
+		Status:  http.StatusRequestEntityTooLarge,
+	}
+}
+
+// NewUnsupportedMediaTypeError creates a new unsupported media type error
+func NewUnsupportedMediaTypeError(message string, err error) *AppError {
+	return &AppError{
+		Code:    ErrUnsupportedMediaType,
+		Message: message,
+		Err:     err,
+		Status:  http.StatusUnsupportedMediaType,
+	}
+}
+
+// FromError returns err as an AppError, wrapping unknown errors as internal server errors
+func FromError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return NewInternalServerError("Internal server error", err)
+}
@@ -0,0 +1,21 @@
+package ids
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// New returns a random identifier with the given prefix, e.g. "gen_3f9a0c1e5b7d2a64"
+func New(prefix string) string {
+	return prefix + "_" + Random(8)
+}
+
+// Random returns n random bytes encoded as a hex string
+func Random(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails if the system entropy source is broken
+		panic("ids: failed to read random bytes: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
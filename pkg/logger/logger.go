@@ -1,89 +1,240 @@
 package logger
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
-	"time"
+	"sort"
+	"strings"
+	"sync/atomic"
 )
 
-// Level represents the logging level
-type Level int
-
+// Output formats
 const (
-	// DEBUG level for detailed information
-	DEBUG Level = iota
-	// INFO level for general operational information
-	INFO
-	// ERROR level for errors that need attention
-	ERROR
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
-// Logger represents the application logger
+// Logger is a structured logger built on log/slog. Child loggers created with With share
+// their parent's level and redaction settings, so changing either applies everywhere.
 type Logger struct {
-	debug *log.Logger
-	info  *log.Logger
-	error *log.Logger
+	logger *slog.Logger
+	level  *slog.LevelVar
+	// redactor scrubs every message and attribute before it is written
+	redactor *atomic.Pointer[Redactor]
+	// contextFields extracts fields such as the request ID for the *Context methods
+	contextFields ContextFields
+}
+
+// ContextFields returns key/value pairs carried by a context to add to log entries
+type ContextFields func(ctx context.Context) []interface{}
+
+// options holds the settings applied by New
+type options struct {
+	format        string
+	level         slog.Level
+	output        io.Writer
+	contextFields ContextFields
 }
 
-// New creates a new Logger instance
-func New() *Logger {
-	flags := log.Ldate | log.Ltime | log.LUTC
+// Option defines a function type for logger configuration
+type Option func(*options)
 
-	return &Logger{
-		debug: log.New(os.Stdout, "DEBUG: ", flags),
-		info:  log.New(os.Stdout, "INFO: ", flags),
-		error: log.New(os.Stderr, "ERROR: ", flags),
+// WithFormat sets the output format, FormatJSON or FormatText
+func WithFormat(format string) Option {
+	return func(o *options) {
+		o.format = format
 	}
 }
 
-// formatMessage formats a log message with fields
-func formatMessage(msg string, fields ...interface{}) string {
-	if len(fields) == 0 {
-		return msg
+// WithLevel sets the minimum level that is written
+func WithLevel(level slog.Level) Option {
+	return func(o *options) {
+		o.level = level
 	}
+}
 
-	// Add timestamp
-	message := fmt.Sprintf("[%s] %s", time.Now().UTC().Format(time.RFC3339), msg)
+// WithOutput sets where log lines are written
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.output = w
+	}
+}
 
-	// Add fields
-	if len(fields) > 0 {
-		message += " |"
-		for i := 0; i < len(fields); i += 2 {
-			if i+1 < len(fields) {
-				message += fmt.Sprintf(" %v=%v", fields[i], fields[i+1])
-			}
-		}
+// WithContextFields adds the fields extracted from the context to entries logged with
+// DebugContext, InfoContext and ErrorContext
+func WithContextFields(fn ContextFields) Option {
+	return func(o *options) {
+		o.contextFields = fn
 	}
+}
+
+// New creates a new Logger instance writing JSON at info level to stdout by default
+func New(opts ...Option) *Logger {
+	o := options{
+		format: FormatJSON,
+		level:  slog.LevelInfo,
+		output: os.Stdout,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	l := &Logger{
+		level:         new(slog.LevelVar),
+		redactor:      new(atomic.Pointer[Redactor]),
+		contextFields: o.contextFields,
+	}
+	l.level.Set(o.level)
+	l.redactor.Store(NewRedactor())
+
+	handlerOpts := &slog.HandlerOptions{
+		Level:       l.level,
+		ReplaceAttr: l.redact,
+	}
+
+	var handler slog.Handler
+	if o.format == FormatText {
+		handler = slog.NewTextHandler(o.output, handlerOpts)
+	} else {
+		handler = slog.NewJSONHandler(o.output, handlerOpts)
+	}
+	l.logger = slog.New(handler)
+
+	return l
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return level, fmt.Errorf("unknown log level %q", name)
+	}
+	return level, nil
+}
+
+// SetRedaction redacts the given field names on top of the defaults, plus prompts when
+// redactPrompts is set
+func (l *Logger) SetRedaction(fields []string, redactPrompts bool) {
+	if redactPrompts {
+		fields = append(append([]string(nil), fields...), PromptFields...)
+	}
+	l.redactor.Store(NewRedactor(fields...))
+}
+
+// Level returns the name of the current minimum level
+func (l *Logger) Level() string {
+	return strings.ToLower(l.level.Level().String())
+}
 
-	return message
+// SetLevel changes the minimum level at runtime
+func (l *Logger) SetLevel(name string) error {
+	level, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	l.level.Set(level)
+	return nil
 }
 
 // Debug logs a debug message
 func (l *Logger) Debug(msg string, fields ...interface{}) {
-	l.debug.Println(formatMessage(msg, fields...))
+	l.log(context.Background(), slog.LevelDebug, msg, fields...)
 }
 
 // Info logs an info message
 func (l *Logger) Info(msg string, fields ...interface{}) {
-	l.info.Println(formatMessage(msg, fields...))
+	l.log(context.Background(), slog.LevelInfo, msg, fields...)
 }
 
 // Error logs an error message
 func (l *Logger) Error(msg string, err error, fields ...interface{}) {
-	// Add error to fields
-	errorFields := append([]interface{}{"error", err.Error()}, fields...)
-	l.error.Println(formatMessage(msg, errorFields...))
+	l.ErrorContext(context.Background(), msg, err, fields...)
+}
+
+// DebugContext logs a debug message with the fields carried by ctx
+func (l *Logger) DebugContext(ctx context.Context, msg string, fields ...interface{}) {
+	l.log(ctx, slog.LevelDebug, msg, fields...)
+}
+
+// InfoContext logs an info message with the fields carried by ctx
+func (l *Logger) InfoContext(ctx context.Context, msg string, fields ...interface{}) {
+	l.log(ctx, slog.LevelInfo, msg, fields...)
+}
+
+// ErrorContext logs an error message with the fields carried by ctx
+func (l *Logger) ErrorContext(ctx context.Context, msg string, err error, fields ...interface{}) {
+	if err != nil {
+		fields = append([]interface{}{"error", err}, fields...)
+	}
+	l.log(ctx, slog.LevelError, msg, fields...)
 }
 
-// WithFields creates a new log entry with fields
+// With returns a child logger that adds the given key/value pairs to every entry
+func (l *Logger) With(fields ...interface{}) *Logger {
+	child := *l
+	child.logger = l.logger.With(fields...)
+	return &child
+}
+
+// WithFields returns a child logger that adds the given fields to every entry
 func (l *Logger) WithFields(fields map[string]interface{}) *Logger {
-	return l
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]interface{}, 0, 2*len(keys))
+	for _, key := range keys {
+		args = append(args, key, fields[key])
+	}
+	return l.With(args...)
 }
 
-// WithError adds an error to the log entry
+// WithError returns a child logger that adds the error to every entry
 func (l *Logger) WithError(err error) *Logger {
-	return l
+	return l.With("error", err)
+}
+
+// log writes an entry, skipping the work of building it when the level is disabled
+func (l *Logger) log(ctx context.Context, level slog.Level, msg string, fields ...interface{}) {
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	if l.contextFields != nil {
+		fields = append(l.contextFields(ctx), fields...)
+	}
+	l.logger.Log(ctx, level, msg, fields...)
+}
+
+// redact scrubs the message and attribute values as they are written
+func (l *Logger) redact(groups []string, attr slog.Attr) slog.Attr {
+	redactor := l.redactor.Load()
+
+	switch attr.Key {
+	case slog.TimeKey, slog.LevelKey, slog.SourceKey:
+		return attr
+	case slog.MessageKey:
+		return slog.String(attr.Key, redactor.Scrub(attr.Value.String()))
+	}
+
+	if redactor.IsRedacted(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+
+	switch value := attr.Value.Resolve(); value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, redactor.Scrub(value.String()))
+	case slog.KindAny:
+		if err, ok := value.Any().(error); ok {
+			return slog.String(attr.Key, redactor.Scrub(err.Error()))
+		}
+	}
+
+	return attr
 }
 
 // Default logger instance
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive values in log output
+const Redacted = "[REDACTED]"
+
+// DefaultRedactedFields are the field names whose values are always redacted
+var DefaultRedactedFields = []string{
+	"key", "api_key", "apikey", "x_api_key", "secret", "token", "access_token",
+	"refresh_token", "authorization", "password", "bootstrap_key",
+}
+
+// PromptFields are redacted as well when prompt redaction is enabled
+var PromptFields = []string{"prompt", "negative_prompt"}
+
+// bearerPattern matches bearer tokens wherever they appear in a message or value
+var bearerPattern = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]+=*`)
+
+// Redactor scrubs sensitive values from log messages and fields. Values of redacted field
+// names are replaced outright; strings are scrubbed of bearer tokens and of redacted
+// fields embedded as JSON ("key":"...") or query parameters (key=...).
+type Redactor struct {
+	fields map[string]bool
+	json   *regexp.Regexp
+	params *regexp.Regexp
+}
+
+// NewRedactor creates a redactor for the default fields plus the given ones
+func NewRedactor(fields ...string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool)}
+
+	var names []string
+	for _, field := range append(append([]string(nil), DefaultRedactedFields...), fields...) {
+		field = normalizeField(field)
+		if field == "" || r.fields[field] {
+			continue
+		}
+		r.fields[field] = true
+		names = append(names, regexp.QuoteMeta(field))
+	}
+
+	alternation := strings.Join(names, "|")
+	r.json = regexp.MustCompile(`(?i)("(?:` + alternation + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	r.params = regexp.MustCompile(`(?i)(\b(?:` + alternation + `)=)[^&\s"]+`)
+
+	return r
+}
+
+// IsRedacted reports whether values of the named field are redacted outright
+func (r *Redactor) IsRedacted(name string) bool {
+	return r.fields[normalizeField(name)]
+}
+
+// Scrub removes bearer tokens and embedded sensitive fields from s
+func (r *Redactor) Scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = r.json.ReplaceAllString(s, `${1}"`+Redacted+`"`)
+	return r.params.ReplaceAllString(s, "${1}"+Redacted)
+}
+
+// normalizeField lowercases a field name and treats dashes as underscores, so header
+// names such as X-API-Key match x_api_key
+func normalizeField(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "-", "_")
+}
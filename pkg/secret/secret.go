@@ -0,0 +1,45 @@
+package secret
+
+import "fmt"
+
+// redacted is what a Secret prints instead of its value
+const redacted = "[REDACTED]"
+
+// Secret holds a sensitive value such as an API key. It refuses to print itself through
+// fmt, logs or JSON encoding; Reveal must be called explicitly to use the value.
+type Secret string
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// IsEmpty reports whether the secret has no value
+func (s Secret) IsEmpty() bool {
+	return s == ""
+}
+
+// String implements fmt.Stringer
+func (s Secret) String() string {
+	return redacted
+}
+
+// GoString implements fmt.GoStringer so %#v is redacted too
+func (s Secret) GoString() string {
+	return redacted
+}
+
+// Format implements fmt.Formatter so every verb prints the redacted form
+func (s Secret) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, redacted)
+}
+
+// MarshalJSON implements json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
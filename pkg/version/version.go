@@ -0,0 +1,29 @@
+package version
+
+import "runtime/debug"
+
+// Build information, injected at link time:
+//
+//	go build -ldflags "-X image/pkg/version.Version=1.4.0 -X image/pkg/version.Commit=$(git rev-parse --short HEAD)" ./cmd/server
+//
+// Commit falls back to the VCS revision Go stamps into builds from a checkout.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+)
+
+func init() {
+	if Commit != "unknown" {
+		return
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && setting.Value != "" {
+			Commit = setting.Value
+		}
+	}
+}